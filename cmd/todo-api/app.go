@@ -10,6 +10,7 @@ import (
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/server"
 	"github.com/alexsniffin/go-api-starter/pkg/config"
 	"github.com/alexsniffin/go-api-starter/pkg/logger"
+	"github.com/alexsniffin/go-api-starter/pkg/signals"
 )
 
 const (
@@ -37,15 +38,33 @@ func main() {
 		os.Exit(2)
 	}
 
+	// settings defaulted in code, e.g. the pool size or share TTL, are logged as loaded and show 0 when unset
+	newLogger.Info().Interface("config", config.Redact(newCfg)).Msg("loaded configuration")
+
+	shutdownSignals, err := signals.Parse(newCfg.ShutdownSignals)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	if len(shutdownSignals) == 0 {
+		shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
 	newLogger.Info().Msg("setting up todo api service")
 	newServer := server.NewServer(newCfg, newLogger)
 	go newServer.Start()
 
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt)
-	signal.Notify(stop, syscall.SIGTERM)
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, append(shutdownSignals, signals.ReloadSignal)...)
 
-	stopped := <-stop
+	stopped := signals.Wait(received, shutdownSignals, func() {
+		reloadedCfg := models.Config{}
+		if err := config.NewConfig(configName, prefix, &reloadedCfg); err != nil {
+			newLogger.Error().Err(err).Msg("failed to reload configuration, keeping the current one")
+			return
+		}
+		newServer.Reload(reloadedCfg)
+	})
 	newLogger.Info().Msg(stopped.String() + " signal received, attempting to gracefully shutdown")
 	newServer.Shutdown(false)
 
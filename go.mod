@@ -9,11 +9,13 @@ require (
 	github.com/go-ozzo/ozzo-validation/v4 v4.2.2
 	github.com/go-pg/pg v8.0.6+incompatible
 	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/json-iterator/go v1.1.12
 	github.com/justinas/alice v1.2.0
 	github.com/onsi/ginkgo v1.12.0 // indirect
 	github.com/onsi/gomega v1.9.0 // indirect
 	github.com/pkg/errors v0.9.1
 	github.com/prometheus/client_golang v1.6.0
+	github.com/rs/xid v1.2.1
 	github.com/rs/zerolog v1.19.0
 	github.com/slok/go-http-metrics v0.8.0
 	github.com/spf13/viper v1.4.0
@@ -22,5 +24,6 @@ require (
 	github.com/unrolled/render v1.0.1
 	github.com/urfave/negroni v1.0.0
 	golang.org/x/net v0.0.0-20200226121028-0de0cce0169b
+	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
 	mellium.im/sasl v0.2.1 // indirect
 )
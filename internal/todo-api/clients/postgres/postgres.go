@@ -1,7 +1,9 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
@@ -11,6 +13,21 @@ import (
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
 )
 
+// defaultPoolSize is the number of connections in the pool when none is configured
+const defaultPoolSize = 20
+
+// migrations bring a todo table created by an earlier version up to date, they're idempotent so they run on every
+// connect and are run on the TodoItem model for ?TableName
+var migrations = []string{
+	"ALTER TABLE ?TableName ADD COLUMN IF NOT EXISTS attempts integer NOT NULL DEFAULT 0",
+	"ALTER TABLE ?TableName ADD COLUMN IF NOT EXISTS updated_on timestamptz NOT NULL DEFAULT now()",
+	"ALTER TABLE ?TableName ADD COLUMN IF NOT EXISTS share_version integer NOT NULL DEFAULT 0",
+}
+
+// todoLengthMigration limits the todo column of a table created by an earlier version to models.TodoMaxLength, it
+// fails while longer todos exist so a failure only leaves the limit to the service
+const todoLengthMigration = "ALTER TABLE ?TableName ALTER COLUMN todo TYPE varchar(1000)"
+
 type DatabaseClient interface {
 	GetConnection() *pg.DB
 	Shutdown() error
@@ -18,48 +35,81 @@ type DatabaseClient interface {
 
 type Client struct {
 	db *pg.DB
+
+	cfg    models.DatabaseConfig
+	logger zerolog.Logger
 }
 
-// Creates a postgres Client
-func NewClient(logger zerolog.Logger, cfg models.DatabaseConfig) (Client, error) {
+// Creates a postgres Client, connections are established lazily so Connect should be used to verify the database
+func NewClient(logger zerolog.Logger, cfg models.DatabaseConfig) Client {
+	poolSize := cfg.PoolSize
+	if poolSize <= 0 {
+		poolSize = defaultPoolSize
+	}
+
 	db := pg.Connect(&pg.Options{
 		User:     cfg.User,
 		Addr:     fmt.Sprint(cfg.Host, ":", cfg.Port),
 		Password: cfg.Password,
 		Database: cfg.DbName,
-		PoolSize: 20,
+		PoolSize: poolSize,
+		// zero uses go-pg's default
+		PoolTimeout: time.Duration(cfg.PoolTimeoutSec) * time.Second,
 	})
 
-	if cfg.CreateTable {
-		err := db.CreateTable((*models.TodoItem)(nil), &orm.CreateTableOptions{
+	return Client{
+		db:     db,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Connect verifies the database is reachable, creating or migrating the todo table if configured and checking the
+// required tables exist
+func (p *Client) Connect() error {
+	if p.cfg.CreateTable {
+		err := p.db.CreateTable((*models.TodoItem)(nil), &orm.CreateTableOptions{
 			Temp:          false,
 			IfNotExists:   false,
 			Varchar:       0,
 			FKConstraints: false,
 		})
 		if err != nil {
-			if err.Error()[:12] != "ERROR #42P07" {
-				return Client{}, errors.Wrap(err, "failed to create todo table")
+			if len(err.Error()) < 12 || err.Error()[:12] != "ERROR #42P07" {
+				return errors.Wrap(err, "failed to create todo table")
 			}
 		}
+
+		for _, migration := range migrations {
+			if _, err := p.db.Model((*models.TodoItem)(nil)).Exec(migration); err != nil {
+				return errors.Wrap(err, "failed to migrate todo table")
+			}
+		}
+		if _, err := p.db.Model((*models.TodoItem)(nil)).Exec(todoLengthMigration); err != nil {
+			p.logger.Warn().Err(err).Msg("failed to limit the todo column length, it's only enforced by the service")
+		}
 	}
 
-	for i := 0; i < len(cfg.Tables); i++ {
+	for i := 0; i < len(p.cfg.Tables); i++ {
 		var check interface{}
-		check, err := db.Exec(`SELECT to_regclass(?)`, cfg.Tables[i])
+		check, err := p.db.Exec(`SELECT to_regclass(?)`, p.cfg.Tables[i])
 		if err != nil {
-			return Client{}, errors.Wrap(err, "failed to execute pg init sql check")
+			return errors.Wrap(err, "failed to execute pg init sql check")
 		}
 		if check == nil {
-			return Client{}, errors.New(fmt.Sprintf("missing required table on pg db: host=%s dbname=%s", cfg.Host, cfg.DbName))
+			return errors.New(fmt.Sprintf("missing required table on pg db: host=%s dbname=%s", p.cfg.Host, p.cfg.DbName))
 		}
 	}
 
-	logger.Info().Msg("connected to pg")
+	p.logger.Info().Msg("connected to pg")
 
-	return Client{
-		db: db,
-	}, nil
+	return nil
+}
+
+// Ping runs a trivial query to check the database is reachable
+func (p *Client) Ping(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx, "SELECT 1")
+	return err
 }
 
 // Return the connection
@@ -0,0 +1,110 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// SyncDispatch calls the subscribers before Publish returns
+	SyncDispatch = "sync"
+	// AsyncDispatch calls the subscribers in order from a background goroutine
+	AsyncDispatch = "async"
+
+	asyncQueueSize = 100
+)
+
+// Subscriber handles a published event, subscribers should type switch on the events they care about
+type Subscriber func(event Event)
+
+// Bus is an in-process event bus which decouples mutations from their side effects
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []Subscriber
+
+	// closeMu guards closed so Publish can't send on the queue once Close closed it
+	closeMu sync.RWMutex
+	closed  bool
+	queue   chan Event
+	done    chan struct{}
+}
+
+// NewBus creates a Bus with the `sync` or `async` dispatch mode, it defaults to `sync`
+func NewBus(dispatch string) (*Bus, error) {
+	switch dispatch {
+	case "", SyncDispatch:
+		return &Bus{}, nil
+	case AsyncDispatch:
+		b := &Bus{
+			queue: make(chan Event, asyncQueueSize),
+			done:  make(chan struct{}),
+		}
+		go b.run()
+		return b, nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unsupported event Dispatch: %s", dispatch))
+	}
+}
+
+// Subscribe registers a subscriber for every published event
+func (b *Bus) Subscribe(subscriber Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers = append(b.subscribers, subscriber)
+}
+
+// Publish dispatches the event to the subscribers, in async mode it blocks while the queue is full. Events published
+// after Close are logged and dropped, e.g. from requests still running when the HTTP server's shutdown timed out.
+func (b *Bus) Publish(event Event) {
+	if b.queue == nil {
+		b.dispatch(event)
+		return
+	}
+
+	b.closeMu.RLock()
+	defer b.closeMu.RUnlock()
+	if b.closed {
+		log.Warn().Str("event", event.Name()).Msg("event published after the bus was closed, dropping it")
+		return
+	}
+	b.queue <- event
+}
+
+// Close waits for queued events to be dispatched, events published afterwards are dropped
+func (b *Bus) Close() {
+	if b.queue == nil {
+		return
+	}
+
+	b.closeMu.Lock()
+	if b.closed {
+		b.closeMu.Unlock()
+		return
+	}
+	b.closed = true
+	close(b.queue)
+	b.closeMu.Unlock()
+
+	<-b.done
+}
+
+func (b *Bus) run() {
+	defer close(b.done)
+
+	for event := range b.queue {
+		b.dispatch(event)
+	}
+}
+
+func (b *Bus) dispatch(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, subscriber := range b.subscribers {
+		subscriber(event)
+	}
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestBus(t *testing.T) {
+	for _, dispatch := range []string{SyncDispatch, AsyncDispatch} {
+		t.Run(dispatch, func(t *testing.T) {
+			bus, err := NewBus(dispatch)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var mu sync.Mutex
+			var received []string
+			for i := 0; i < 2; i++ {
+				bus.Subscribe(func(event Event) {
+					mu.Lock()
+					defer mu.Unlock()
+					received = append(received, event.Name())
+				})
+			}
+
+			bus.Publish(TodoCreated{})
+			bus.Publish(TodoDeleted{ID: 1})
+			bus.Close()
+
+			expected := []string{"todo_created", "todo_created", "todo_deleted", "todo_deleted"}
+			if !reflect.DeepEqual(received, expected) {
+				t.Errorf("unexpected events: got %v want %v", received, expected)
+			}
+		})
+	}
+
+	t.Run("publishAfterClose", func(t *testing.T) {
+		bus, err := NewBus(AsyncDispatch)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		received := 0
+		bus.Subscribe(func(Event) {
+			received++
+		})
+		bus.Publish(TodoCreated{})
+		bus.Close()
+
+		// would panic sending on the closed queue
+		bus.Publish(TodoDeleted{ID: 1})
+		bus.Close()
+
+		if received != 1 {
+			t.Errorf("unexpected number of events: got %v want %v", received, 1)
+		}
+	})
+
+	t.Run("unsupportedDispatch", func(t *testing.T) {
+		if _, err := NewBus("unknown"); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// Event is a domain event published after a successful store mutation
+type Event interface {
+	Name() string
+}
+
+// TodoCreated is published after a todo is inserted
+type TodoCreated struct {
+	Item models.TodoItem
+}
+
+func (TodoCreated) Name() string {
+	return "todo_created"
+}
+
+// TodoDeleted is published after a todo is deleted
+type TodoDeleted struct {
+	ID int
+}
+
+func (TodoDeleted) Name() string {
+	return "todo_deleted"
+}
+
+// TodoUpdated is published after a todo is changed, e.g. touched
+type TodoUpdated struct {
+	ID int
+}
+
+func (TodoUpdated) Name() string {
+	return "todo_updated"
+}
@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/auth"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/readonly"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/response"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
+)
+
+// bearerPrefix is the required scheme of the admin Authorization header
+const bearerPrefix = "Bearer "
+
+// NewHandlerFunc creates a middleware which responds with 401 unless the request carries `Authorization: Bearer <token>`
+// for the admin token, it doesn't depend on any other auth setting. Without a configured token every request is
+// rejected. Admin requests are access logged.
+func NewHandlerFunc(logger zerolog.Logger, render renderer.Renderer, token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization := r.Header.Get("Authorization")
+			given := strings.TrimPrefix(authorization, bearerPrefix)
+			if token == "" || given == authorization ||
+				subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				logger.Warn().Caller().Str("verb", r.Method).Stringer("url", r.URL).Msg("unauthorized admin request")
+				auth.WriteUnauthenticated(logger, render, w, `Bearer realm="admin"`, "missing or invalid admin token")
+				return
+			}
+
+			hlog.FromRequest(r).Info().
+				Str("principal", "admin").
+				Str("verb", r.Method).
+				Stringer("url", r.URL).
+				Msg("admin request")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IndexAdvisor reports the store's queried columns which are missing an index
+type IndexAdvisor interface {
+	IndexAdvice(ctx context.Context) (models.IndexAdvice, error)
+}
+
+type Handler struct {
+	logger zerolog.Logger
+
+	render   renderer.Renderer
+	readOnly *readonly.Mode
+	advisor  IndexAdvisor
+}
+
+// Creates the admin handler
+func NewHandler(logger zerolog.Logger, render renderer.Renderer, readOnly *readonly.Mode, advisor IndexAdvisor) Handler {
+	return Handler{
+		logger:   logger,
+		render:   render,
+		readOnly: readOnly,
+		advisor:  advisor,
+	}
+}
+
+// Handle HTTP Put to enable or disable read-only mode
+func (h *Handler) PutReadOnly(w http.ResponseWriter, r *http.Request) {
+	var req models.ReadOnlyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		response.WriteError(h.logger, h.render, w, http.StatusBadRequest, "enabled is required")
+		return
+	}
+
+	h.readOnly.SetEnabled(*req.Enabled)
+	h.logger.Info().Bool("enabled", *req.Enabled).Msg("read-only mode changed")
+
+	if err := h.render.JSON(w, http.StatusOK, models.ReadOnlyRequest{Enabled: req.Enabled}); err != nil {
+		h.logger.Error().Caller().Err(err).Msg("failed to marshal json response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// Handle HTTP Get for the index advice, it only reads the database's catalogs and statistics
+func (h *Handler) GetIndexAdvice(w http.ResponseWriter, r *http.Request) {
+	advice, err := h.advisor.IndexAdvice(r.Context())
+	if err != nil {
+		hlog.FromRequest(r).Error().Caller().Err(err).Msg("failed to get index advice")
+		response.WriteError(h.logger, h.render, w, http.StatusInternalServerError, "Internal server error with request")
+		return
+	}
+
+	if err := h.render.JSON(w, http.StatusOK, advice); err != nil {
+		h.logger.Error().Caller().Err(err).Msg("failed to marshal json response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
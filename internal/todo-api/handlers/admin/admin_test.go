@@ -0,0 +1,123 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/render"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/readonly"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// staticAdvisor returns fixed index advice
+type staticAdvisor struct {
+	advice models.IndexAdvice
+}
+
+func (a staticAdvisor) IndexAdvice(_ context.Context) (models.IndexAdvice, error) {
+	return a.advice, nil
+}
+
+func initAdminHandler(token string, mode *readonly.Mode) http.Handler {
+	logger := zerolog.New(os.Stdout)
+	handler := NewHandler(logger, render.New(), mode, staticAdvisor{})
+	return NewHandlerFunc(logger, render.New(), token)(http.HandlerFunc(handler.PutReadOnly))
+}
+
+func TestAdminHandler(t *testing.T) {
+	cases := []struct {
+		name          string
+		token         string
+		authorization string
+	}{
+		{"noCredentials", "secret", ""},
+		{"wrongToken", "secret", "Bearer wrong"},
+		{"missingScheme", "secret", "secret"},
+		{"otherScheme", "secret", "Basic secret"},
+		{"noTokenConfigured", "", "Bearer "},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mode := &readonly.Mode{}
+			req := httptest.NewRequest("PUT", "/admin/readonly", strings.NewReader(`{"enabled":true}`))
+			if c.authorization != "" {
+				req.Header.Set("Authorization", c.authorization)
+			}
+
+			rr := httptest.NewRecorder()
+			initAdminHandler(c.token, mode).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusUnauthorized {
+				t.Errorf("unexpected status code: got %v want %v", status, http.StatusUnauthorized)
+			}
+			if challenge := rr.Header().Get("WWW-Authenticate"); challenge != `Bearer realm="admin"` {
+				t.Errorf("unexpected WWW-Authenticate: got %v want %v", challenge, `Bearer realm="admin"`)
+			}
+			if mode.IsEnabled() {
+				t.Error("expected read-only mode to be unchanged")
+			}
+		})
+	}
+
+	t.Run("putReadOnly", func(t *testing.T) {
+		mode := &readonly.Mode{}
+		req := httptest.NewRequest("PUT", "/admin/readonly", strings.NewReader(`{"enabled":true}`))
+		req.Header.Set("Authorization", "Bearer secret")
+
+		rr := httptest.NewRecorder()
+		initAdminHandler("secret", mode).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+		if rr.Body.String() != `{"enabled":true}` {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), `{"enabled":true}`)
+		}
+		if !mode.IsEnabled() {
+			t.Error("expected read-only mode to be enabled")
+		}
+	})
+
+	t.Run("putReadOnlyMissingEnabled", func(t *testing.T) {
+		req := httptest.NewRequest("PUT", "/admin/readonly", strings.NewReader(`{}`))
+		req.Header.Set("Authorization", "Bearer secret")
+
+		rr := httptest.NewRecorder()
+		initAdminHandler("secret", &readonly.Mode{}).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("degradedIndexAdvice", func(t *testing.T) {
+		logger := zerolog.New(os.Stdout)
+		handler := NewHandler(logger, render.New(), &readonly.Mode{}, staticAdvisor{advice: models.IndexAdvice{
+			Degraded: true,
+			Missing: []models.ColumnAdvice{
+				{Table: "todo_items", Column: "created_on", Suggestion: "CREATE INDEX CONCURRENTLY ON todo_items (created_on)"},
+			},
+		}})
+		req := httptest.NewRequest("GET", "/admin/index-advice", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+
+		rr := httptest.NewRecorder()
+		NewHandlerFunc(logger, render.New(), "secret")(http.HandlerFunc(handler.GetIndexAdvice)).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+		expected := `{"degraded":true,"missing":[{"table":"todo_items","column":"created_on","suggestion":"CREATE INDEX CONCURRENTLY ON todo_items (created_on)"}]}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/response"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
+)
+
+// Error codes for failed auth, every auth middleware responds through this package so clients can rely on them:
+// missing or invalid credentials are 401 with a `WWW-Authenticate` challenge, valid credentials without permission
+// for the request are 403.
+const (
+	CodeUnauthenticated = "unauthenticated"
+	CodeForbidden       = "forbidden"
+)
+
+// WriteUnauthenticated responds with 401 and the challenge for the expected auth scheme, e.g. `Bearer realm="admin"`
+func WriteUnauthenticated(logger zerolog.Logger, render renderer.Renderer, w http.ResponseWriter, challenge, message string) {
+	w.Header().Set("WWW-Authenticate", challenge)
+	response.WriteErrorCode(logger, render, w, http.StatusUnauthorized, CodeUnauthenticated, message)
+}
+
+// WriteForbidden responds with 403 for authenticated requests lacking permission
+func WriteForbidden(logger zerolog.Logger, render renderer.Renderer, w http.ResponseWriter, message string) {
+	response.WriteErrorCode(logger, render, w, http.StatusForbidden, CodeForbidden, message)
+}
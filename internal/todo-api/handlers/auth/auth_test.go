@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/render"
+)
+
+func TestAuthResponses(t *testing.T) {
+	logger := zerolog.New(os.Stdout)
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		WriteUnauthenticated(logger, render.New(), rr, `Bearer realm="admin"`, "missing credentials")
+
+		if status := rr.Code; status != http.StatusUnauthorized {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusUnauthorized)
+		}
+		if challenge := rr.Header().Get("WWW-Authenticate"); challenge != `Bearer realm="admin"` {
+			t.Errorf("unexpected WWW-Authenticate: got %v want %v", challenge, `Bearer realm="admin"`)
+		}
+
+		expected := `{"code":"unauthenticated","message":"missing credentials"}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		WriteForbidden(logger, render.New(), rr, "not allowed")
+
+		if status := rr.Code; status != http.StatusForbidden {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusForbidden)
+		}
+		if challenge := rr.Header().Get("WWW-Authenticate"); challenge != "" {
+			t.Errorf("unexpected WWW-Authenticate: %v", challenge)
+		}
+
+		expected := `{"code":"forbidden","message":"not allowed"}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package bodylog
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/rs/zerolog/hlog"
+)
+
+// NewHandlerFunc creates a middleware which debug logs up to maxBytes of the request body once the handler returns,
+// zero or less disables it. The body is copied as the handler reads it, so it's only consumed once and the handler
+// still receives all of it when the log is truncated.
+func NewHandlerFunc(maxBytes int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if maxBytes <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			logged := &cappedBuffer{max: maxBytes}
+			r.Body = teeReadCloser{Reader: io.TeeReader(r.Body, logged), Closer: r.Body}
+
+			next.ServeHTTP(w, r)
+
+			hlog.FromRequest(r).Debug().
+				Str("body", logged.String()).
+				Bool("body_truncated", logged.truncated).
+				Msg("HTTP Request body")
+		})
+	}
+}
+
+// teeReadCloser reads through the tee while closing the original body
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// cappedBuffer keeps the first max bytes written to it and discards the rest
+type cappedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+// Write never fails so the tee keeps passing the whole body through
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.max - b.Len(); len(p) > remaining {
+		b.Buffer.Write(p[:remaining])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
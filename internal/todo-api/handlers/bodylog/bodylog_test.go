@@ -0,0 +1,67 @@
+package bodylog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+)
+
+func TestBodyLogHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		maxBytes  int
+		body      string
+		logged    string
+		truncated bool
+	}{
+		{"wholeBody", 64, `{"todo":"test"}`, `{"todo":"test"}`, false},
+		{"truncated", 8, `{"todo":"test"}`, `{"todo":`, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var decoded struct {
+				Todo string `json:"todo"`
+			}
+			var decodeErr error
+			handler := hlog.NewHandler(zerolog.New(&buf))(NewHandlerFunc(c.maxBytes)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				decodeErr = json.NewDecoder(r.Body).Decode(&decoded)
+			})))
+
+			req := httptest.NewRequest("POST", "/api/todo", strings.NewReader(c.body))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if decodeErr != nil || decoded.Todo != "test" {
+				t.Errorf("unexpected decoded body: got %v, %v want test", decoded.Todo, decodeErr)
+			}
+
+			var entry struct {
+				Body      string `json:"body"`
+				Truncated bool   `json:"body_truncated"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatal(err)
+			}
+			if entry.Body != c.logged || entry.Truncated != c.truncated {
+				t.Errorf("unexpected log entry: got %v, %v want %v, %v", entry.Body, entry.Truncated, c.logged, c.truncated)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := hlog.NewHandler(zerolog.New(&buf))(NewHandlerFunc(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/todo", strings.NewReader(`{"todo":"test"}`)))
+
+		if buf.Len() != 0 {
+			t.Errorf("unexpected log entry: got %v", buf.String())
+		}
+	})
+}
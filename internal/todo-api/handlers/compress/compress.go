@@ -0,0 +1,135 @@
+package compress
+
+import (
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NewHandlerFunc creates a middleware which gzip compresses JSON and text responses for clients accepting gzip. The
+// level ranges from 1 (best speed) to 9 (best compression), 0 uses gzip's default.
+func NewHandlerFunc(level int) (func(http.Handler) http.Handler, error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	} else if level < gzip.BestSpeed || level > gzip.BestCompression {
+		return nil, errors.New(fmt.Sprintf("unsupported CompressionLevel: %d", level))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				level:          level,
+				head:           r.Method == http.MethodHead,
+			}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}, nil
+}
+
+// acceptsGzip reports whether the Accept-Encoding header accepts gzip with a quality above 0. An explicit gzip entry
+// takes precedence over `*`, so `gzip;q=0` or `identity` alone never get a compressed response
+func acceptsGzip(header string) bool {
+	gzipQuality, wildcardQuality := -1.0, -1.0
+	for _, encoding := range strings.Split(header, ",") {
+		params := strings.Split(encoding, ";")
+		name := strings.TrimSpace(params[0])
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if len(param) > 2 && strings.EqualFold(param[:2], "q=") {
+				q, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil || q < 0 || q > 1 {
+					q = 0
+				}
+				quality = q
+			}
+		}
+
+		if strings.EqualFold(name, "gzip") {
+			gzipQuality = quality
+		} else if name == "*" {
+			wildcardQuality = quality
+		}
+	}
+
+	if gzipQuality >= 0 {
+		return gzipQuality > 0
+	}
+	return wildcardQuality > 0
+}
+
+// compressWriter decides whether to compress when the header is written
+type compressWriter struct {
+	http.ResponseWriter
+
+	level       int
+	head        bool
+	gzipWriter  *gzip.Writer
+	wroteHeader bool
+}
+
+func (c *compressWriter) WriteHeader(statusCode int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+
+	if c.shouldCompress(statusCode) {
+		c.Header().Set("Content-Encoding", "gzip")
+		c.Header().Del("Content-Length")
+		// the level is validated when the middleware is created
+		c.gzipWriter, _ = gzip.NewWriterLevel(c.ResponseWriter, c.level)
+	}
+	c.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (c *compressWriter) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.gzipWriter != nil {
+		return c.gzipWriter.Write(b)
+	}
+	return c.ResponseWriter.Write(b)
+}
+
+func (c *compressWriter) Flush() {
+	if c.gzipWriter != nil {
+		_ = c.gzipWriter.Flush()
+	}
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (c *compressWriter) shouldCompress(statusCode int) bool {
+	if c.head || statusCode < http.StatusOK || statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
+		return false
+	}
+	if c.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := c.Header().Get("Content-Type")
+	return strings.HasPrefix(contentType, "application/json") || strings.HasPrefix(contentType, "text/")
+}
+
+func (c *compressWriter) close() {
+	if c.gzipWriter != nil {
+		_ = c.gzipWriter.Close()
+	}
+}
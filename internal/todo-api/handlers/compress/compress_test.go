@@ -0,0 +1,124 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var jsonBody = strings.Repeat(`{"id":1,"todo":"test"}`, 100)
+
+func initCompressHandler(t *testing.T, level int) http.Handler {
+	jsonHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(jsonBody))
+	})
+
+	handlerFunc, err := NewHandlerFunc(level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handlerFunc(jsonHandler)
+}
+
+func TestCompressHandler(t *testing.T) {
+	t.Run("gzip", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/todo/1", nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+
+		rr := httptest.NewRecorder()
+		initCompressHandler(t, 0).ServeHTTP(rr, req)
+
+		if encoding := rr.Header().Get("Content-Encoding"); encoding != "gzip" {
+			t.Errorf("unexpected Content-Encoding: got %v want %v", encoding, "gzip")
+			t.FailNow()
+		}
+
+		reader, err := gzip.NewReader(rr.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != jsonBody {
+			t.Errorf("unexpected body: got %v want %v", string(body), jsonBody)
+		}
+	})
+
+	t.Run("notAccepted", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		initCompressHandler(t, 0).ServeHTTP(rr, httptest.NewRequest("GET", "/api/todo/1", nil))
+
+		if encoding := rr.Header().Get("Content-Encoding"); encoding != "" {
+			t.Errorf("unexpected Content-Encoding: %v", encoding)
+		}
+		if rr.Body.String() != jsonBody {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), jsonBody)
+		}
+	})
+
+	t.Run("qualityValues", func(t *testing.T) {
+		cases := map[string]bool{
+			"gzip;q=0":           false,
+			"gzip; q=0.0, *":     false,
+			"identity":           false,
+			"*;q=0":              false,
+			"gzip;q=0.5":         true,
+			"*":                  true,
+			"br, GZIP;q=1":       true,
+			"identity, *;q=0.1":  true,
+			"gzip;q=bogus, *":    false,
+			"deflate, identity;": false,
+		}
+		for header, compressed := range cases {
+			req := httptest.NewRequest("GET", "/api/todo/1", nil)
+			req.Header.Set("Accept-Encoding", header)
+
+			rr := httptest.NewRecorder()
+			initCompressHandler(t, 0).ServeHTTP(rr, req)
+
+			if encoding := rr.Header().Get("Content-Encoding"); (encoding == "gzip") != compressed {
+				t.Errorf("unexpected Content-Encoding for %q: %v", header, encoding)
+			}
+			if !compressed && rr.Body.String() != jsonBody {
+				t.Errorf("unexpected body for %q: got %v want %v", header, rr.Body.String(), jsonBody)
+			}
+		}
+	})
+
+	// the gzip header's extra flags record whether the best speed or best compression level was used
+	t.Run("configuredLevel", func(t *testing.T) {
+		cases := []struct {
+			level         int
+			expectedFlags byte
+		}{
+			{gzip.BestSpeed, 4},
+			{gzip.BestCompression, 2},
+		}
+		for _, c := range cases {
+			req := httptest.NewRequest("GET", "/api/todo/1", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+
+			rr := httptest.NewRecorder()
+			initCompressHandler(t, c.level).ServeHTTP(rr, req)
+
+			if flags := rr.Body.Bytes()[8]; flags != c.expectedFlags {
+				t.Errorf("unexpected extra flags for level %v: got %v want %v", c.level, flags, c.expectedFlags)
+			}
+		}
+	})
+
+	t.Run("invalidLevel", func(t *testing.T) {
+		for _, level := range []int{-2, 10} {
+			if _, err := NewHandlerFunc(level); err == nil {
+				t.Errorf("expected error for level %v", level)
+			}
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package decompress
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/response"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
+)
+
+// defaultMaxBytes limits the decompressed body when no limit is configured
+const defaultMaxBytes = 1 << 20
+
+// NewHandlerFunc creates a middleware which decompresses request bodies sent with `Content-Encoding: gzip`, malformed
+// gzip returns 400. The decompressed body is limited to maxBytes so small payloads can't expand without bound.
+func NewHandlerFunc(logger zerolog.Logger, render renderer.Renderer, maxBytes int64) func(http.Handler) http.Handler {
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || !strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gzipReader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				logger.Debug().Caller().Err(err).Msg("malformed gzip request body")
+				response.WriteError(logger, render, w, http.StatusBadRequest, "malformed gzip body")
+				return
+			}
+			defer gzipReader.Close()
+
+			r.Body = http.MaxBytesReader(w, gzipReader, maxBytes)
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/render"
+)
+
+func initDecompressHandler() http.Handler {
+	echoHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	})
+	return NewHandlerFunc(zerolog.New(os.Stdout), render.New(), 1024)(echoHandler)
+}
+
+func gzipBody(t *testing.T, body string) *bytes.Buffer {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	if _, err := gzipWriter.Write([]byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestDecompressHandler(t *testing.T) {
+	t.Run("gzipBody", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/todo/", gzipBody(t, `{"todo":"test"}`))
+		req.Header.Set("Content-Encoding", "gzip")
+
+		rr := httptest.NewRecorder()
+		initDecompressHandler().ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+		if rr.Body.String() != `{"todo":"test"}` {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), `{"todo":"test"}`)
+		}
+	})
+
+	t.Run("plainBody", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		initDecompressHandler().ServeHTTP(rr, httptest.NewRequest("POST", "/api/todo/", strings.NewReader(`{"todo":"test"}`)))
+
+		if rr.Body.String() != `{"todo":"test"}` {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), `{"todo":"test"}`)
+		}
+	})
+
+	t.Run("malformedGzip", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/todo/", strings.NewReader(`{"todo":"test"}`))
+		req.Header.Set("Content-Encoding", "gzip")
+
+		rr := httptest.NewRecorder()
+		initDecompressHandler().ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+			t.FailNow()
+		}
+
+		expected := `{"message":"malformed gzip body"}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+	})
+
+	t.Run("decompressedTooLarge", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/todo/", gzipBody(t, strings.Repeat("a", 1025)))
+		req.Header.Set("Content-Encoding", "gzip")
+
+		rr := httptest.NewRecorder()
+		initDecompressHandler().ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+}
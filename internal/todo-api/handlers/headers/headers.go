@@ -0,0 +1,43 @@
+package headers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/response"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
+)
+
+// NewHandlerFunc creates a middleware which responds with 400 when any of the required headers is missing, the values
+// are added to the request logger, e.g. `X-Client-ID` as `x_client_id`
+func NewHandlerFunc(logger zerolog.Logger, render renderer.Renderer, required []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, header := range required {
+				if r.Header.Get(header) == "" {
+					logger.Debug().Caller().Str("header", header).Msg("missing required header")
+					response.WriteError(logger, render, w, http.StatusBadRequest, fmt.Sprint("missing required header: ", header))
+					return
+				}
+			}
+
+			if len(required) > 0 {
+				zerolog.Ctx(r.Context()).UpdateContext(func(c zerolog.Context) zerolog.Context {
+					for _, header := range required {
+						c = c.Str(logField(header), r.Header.Get(header))
+					}
+					return c
+				})
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func logField(header string) string {
+	return strings.ToLower(strings.ReplaceAll(header, "-", "_"))
+}
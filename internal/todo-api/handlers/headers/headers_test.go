@@ -0,0 +1,85 @@
+package headers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+	"github.com/unrolled/render"
+)
+
+func initHeadersHandler(required []string) http.Handler {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hlog.FromRequest(r).Info().Msg("handled")
+		w.WriteHeader(http.StatusOK)
+	})
+	return NewHandlerFunc(zerolog.New(os.Stdout), render.New(), required)(okHandler)
+}
+
+func TestHeadersHandler(t *testing.T) {
+	required := []string{"X-Client-ID", "X-App-Version"}
+
+	t.Run("present", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/todo/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Client-ID", "web")
+		req.Header.Set("X-App-Version", "1.2.0")
+
+		var buf bytes.Buffer
+		rr := httptest.NewRecorder()
+		hlog.NewHandler(zerolog.New(&buf))(initHeadersHandler(required)).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+
+		for _, expected := range []string{`"x_client_id":"web"`, `"x_app_version":"1.2.0"`} {
+			if !strings.Contains(buf.String(), expected) {
+				t.Errorf("expected %v in log: %v", expected, buf.String())
+			}
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/todo/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Client-ID", "web")
+
+		rr := httptest.NewRecorder()
+		initHeadersHandler(required).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+			t.FailNow()
+		}
+
+		expected := `{"message":"missing required header: X-App-Version"}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+	})
+
+	t.Run("noneRequired", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/todo/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		initHeadersHandler(nil).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+		}
+	})
+}
@@ -4,19 +4,37 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi"
 	"github.com/justinas/alice"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/hlog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
 )
 
+// unmatchedRoute labels the response sizes of requests which didn't match a route
+const unmatchedRoute = "unmatched"
+
+var responseSizes = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "todo_api_response_size_bytes",
+	Help:    "Size of response bodies as written to the client, by route.",
+	Buckets: prometheus.ExponentialBuckets(128, 4, 8),
+}, []string{"route"})
+
+// NewHandlerFunc creates the request logging middleware, every request is access logged with the number of bytes
+// written which is also recorded in a histogram by route. The size is after compression since the middleware wraps
+// the compressor.
 func NewHandlerFunc(logger zerolog.Logger) func(http.Handler) http.Handler {
 	c := alice.New()
 	c = c.Append(hlog.NewHandler(logger))
 	c = c.Append(hlog.RemoteAddrHandler("ip"))
 	c = c.Append(hlog.UserAgentHandler("agent"))
 	c = c.Append(hlog.RefererHandler("referer"))
-	c = c.Append(hlog.RequestIDHandler("req_id", "Request-Id"))
+	c = c.Append(requestIDHandler("req_id"))
 	c = c.Append(hlog.AccessHandler(func(r *http.Request, status, size int, duration time.Duration) {
+		responseSizes.WithLabelValues(routePattern(r)).Observe(float64(size))
 		hlog.FromRequest(r).Info().
 			Str("verb", r.Method).
 			Stringer("url", r.URL).
@@ -28,3 +46,28 @@ func NewHandlerFunc(logger zerolog.Logger) func(http.Handler) http.Handler {
 
 	return c.Then
 }
+
+// routePattern returns the pattern of the route the request matched, it's only complete once the router has served
+// the request
+func routePattern(r *http.Request) string {
+	if rCtx := chi.RouteContext(r.Context()); rCtx != nil {
+		if pattern := rCtx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return unmatchedRoute
+}
+
+// requestIDHandler adds the request id set by the requestid middleware to the request's log context
+func requestIDHandler(fieldKey string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id, ok := utils.RequestID(r.Context()); ok {
+				hlog.FromRequest(r).UpdateContext(func(c zerolog.Context) zerolog.Context {
+					return c.Str(fieldKey, id)
+				})
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+)
+
+func TestLoggingHandler(t *testing.T) {
+	t.Run("responseSize", func(t *testing.T) {
+		body := `{"id":1,"todo":"` + strings.Repeat("a", 500) + `"}`
+
+		var buf bytes.Buffer
+		r := chi.NewRouter()
+		r.Use(NewHandlerFunc(zerolog.New(&buf)))
+		r.Get("/api/todo/{id}", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(body))
+		})
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/todo/1", nil))
+
+		var entry struct {
+			Size int `json:"size"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Size != len(body) || rr.Body.Len() != len(body) {
+			t.Errorf("unexpected logged size: got %v want %v", entry.Size, len(body))
+		}
+
+		expected := `
+# HELP todo_api_response_size_bytes Size of response bodies as written to the client, by route.
+# TYPE todo_api_response_size_bytes histogram
+todo_api_response_size_bytes_bucket{route="/api/todo/{id}",le="128"} 0
+todo_api_response_size_bytes_bucket{route="/api/todo/{id}",le="512"} 0
+todo_api_response_size_bytes_bucket{route="/api/todo/{id}",le="2048"} 1
+todo_api_response_size_bytes_bucket{route="/api/todo/{id}",le="8192"} 1
+todo_api_response_size_bytes_bucket{route="/api/todo/{id}",le="32768"} 1
+todo_api_response_size_bytes_bucket{route="/api/todo/{id}",le="131072"} 1
+todo_api_response_size_bytes_bucket{route="/api/todo/{id}",le="524288"} 1
+todo_api_response_size_bytes_bucket{route="/api/todo/{id}",le="2.097152e+06"} 1
+todo_api_response_size_bytes_bucket{route="/api/todo/{id}",le="+Inf"} 1
+todo_api_response_size_bytes_sum{route="/api/todo/{id}"} 518
+todo_api_response_size_bytes_count{route="/api/todo/{id}"} 1
+`
+		if err := testutil.CollectAndCompare(responseSizes, strings.NewReader(expected)); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package nostore
+
+import (
+	"net/http"
+	"strings"
+)
+
+// NewHandlerFunc creates a middleware which marks responses for paths under any of the prefixes as not cacheable, so
+// intermediaries don't store sensitive data
+func NewHandlerFunc(prefixes []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					w.Header().Set("Cache-Control", "no-store")
+					w.Header().Set("Pragma", "no-cache")
+					break
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
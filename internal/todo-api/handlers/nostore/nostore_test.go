@@ -0,0 +1,35 @@
+package nostore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNoStoreHandler(t *testing.T) {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := NewHandlerFunc([]string{"/api/todo"})(okHandler)
+
+	t.Run("sensitiveRoute", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/todo/1", nil))
+
+		if cacheControl := rr.Header().Get("Cache-Control"); cacheControl != "no-store" {
+			t.Errorf("unexpected Cache-Control: got %v want %v", cacheControl, "no-store")
+		}
+		if pragma := rr.Header().Get("Pragma"); pragma != "no-cache" {
+			t.Errorf("unexpected Pragma: got %v want %v", pragma, "no-cache")
+		}
+	})
+
+	t.Run("otherRoute", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/health", nil))
+
+		if cacheControl := rr.Header().Get("Cache-Control"); cacheControl != "" {
+			t.Errorf("unexpected Cache-Control: %v", cacheControl)
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package options
+
+import (
+	"net/http"
+	"strings"
+)
+
+// NewHandlerFunc creates a middleware which responds to the server-wide `OPTIONS *` request with 204 and an `Allow`
+// header listing the allowed methods, other requests are passed through since chi can't route the asterisk path
+func NewHandlerFunc(allowedMethods []string) func(http.Handler) http.Handler {
+	allow := strings.Join(allowedMethods, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions && r.RequestURI == "*" {
+				w.Header().Set("Allow", allow)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package options
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func initOptionsHandler() http.Handler {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return NewHandlerFunc([]string{"GET", "POST", "OPTIONS"})(okHandler)
+}
+
+func TestOptionsHandler(t *testing.T) {
+	t.Run("asteriskForm", func(t *testing.T) {
+		req := httptest.NewRequest("OPTIONS", "*", nil)
+
+		rr := httptest.NewRecorder()
+		initOptionsHandler().ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusNoContent {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusNoContent)
+			t.FailNow()
+		}
+
+		expected := "GET, POST, OPTIONS"
+		if allow := rr.Header().Get("Allow"); allow != expected {
+			t.Errorf("unexpected allow header: got %v want %v", allow, expected)
+		}
+	})
+
+	t.Run("pathForm", func(t *testing.T) {
+		req := httptest.NewRequest("OPTIONS", "/api/todo/", nil)
+
+		rr := httptest.NewRecorder()
+		initOptionsHandler().ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+		}
+		if allow := rr.Header().Get("Allow"); allow != "" {
+			t.Errorf("unexpected allow header: %v", allow)
+		}
+	})
+}
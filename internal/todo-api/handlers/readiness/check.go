@@ -0,0 +1,80 @@
+package readiness
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/response"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
+)
+
+// errGateClosed is returned by a check while the gate is closed, e.g. before the database is first connected
+var errGateClosed = errors.New("server is not ready")
+
+// Pinger checks a data dependency is reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Checker checks readiness by pinging the data dependencies, a successful check is reused for the ttl so frequent
+// polling from many replicas doesn't add load to the database. Failures aren't cached so recovery is seen immediately.
+type Checker struct {
+	gate   *Gate
+	pinger Pinger
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	checkedAt time.Time
+}
+
+// NewChecker creates a Checker, a ttl of 0 or less pings on every check
+func NewChecker(gate *Gate, pinger Pinger, ttl time.Duration) *Checker {
+	return &Checker{
+		gate:   gate,
+		pinger: pinger,
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// Check returns nil when the gate is open and the last successful ping is within the ttl or a new ping succeeds.
+// Concurrent checks wait for the same ping instead of each pinging.
+func (c *Checker) Check(ctx context.Context) error {
+	if !c.gate.IsReady() {
+		return errGateClosed
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.ttl > 0 && !c.checkedAt.IsZero() && now.Sub(c.checkedAt) < c.ttl {
+		return nil
+	}
+
+	if err := c.pinger.Ping(ctx); err != nil {
+		c.checkedAt = time.Time{}
+		return err
+	}
+	c.checkedAt = now
+	return nil
+}
+
+// NewReadyHandler creates a handler which responds with 200 when the checker passes and 503 otherwise
+func NewReadyHandler(logger zerolog.Logger, render renderer.Renderer, checker *Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := checker.Check(r.Context()); err != nil {
+			logger.Warn().Caller().Err(err).Msg("readiness check failed")
+			response.WriteError(logger, render, w, http.StatusServiceUnavailable, "Service unavailable, try again later")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
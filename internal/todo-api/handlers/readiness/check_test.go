@@ -0,0 +1,93 @@
+package readiness
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/render"
+)
+
+// countPinger counts pings and fails them while err is set
+type countPinger struct {
+	pings int
+	err   error
+}
+
+func (p *countPinger) Ping(_ context.Context) error {
+	p.pings++
+	return p.err
+}
+
+func TestChecker(t *testing.T) {
+	initChecker := func(ttl time.Duration) (*Checker, *countPinger, *time.Time) {
+		gate := &Gate{}
+		gate.SetReady(true)
+		pinger := &countPinger{}
+		checker := NewChecker(gate, pinger, ttl)
+		now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+		checker.now = func() time.Time { return now }
+		return checker, pinger, &now
+	}
+
+	t.Run("rapidChecksShareOnePing", func(t *testing.T) {
+		checker, pinger, now := initChecker(time.Second)
+		handler := NewReadyHandler(zerolog.New(os.Stdout), render.New(), checker)
+
+		for i := 0; i < 2; i++ {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/ready", nil))
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			}
+			*now = now.Add(100 * time.Millisecond)
+		}
+		if pinger.pings != 1 {
+			t.Errorf("unexpected pings: got %v want %v", pinger.pings, 1)
+		}
+
+		*now = now.Add(time.Second)
+		if err := checker.Check(context.Background()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if pinger.pings != 2 {
+			t.Errorf("expected a ping after the ttl: got %v want %v", pinger.pings, 2)
+		}
+	})
+
+	t.Run("failuresNotCached", func(t *testing.T) {
+		checker, pinger, _ := initChecker(time.Second)
+		pinger.err = errors.New("connection refused")
+		handler := NewReadyHandler(zerolog.New(os.Stdout), render.New(), checker)
+
+		for i := 0; i < 2; i++ {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/ready", nil))
+
+			if status := rr.Code; status != http.StatusServiceUnavailable {
+				t.Errorf("unexpected status code: got %v want %v", status, http.StatusServiceUnavailable)
+			}
+		}
+		if pinger.pings != 2 {
+			t.Errorf("unexpected pings: got %v want %v", pinger.pings, 2)
+		}
+	})
+
+	t.Run("gateClosed", func(t *testing.T) {
+		checker, pinger, _ := initChecker(time.Second)
+		checker.gate.SetReady(false)
+
+		if err := checker.Check(context.Background()); err != errGateClosed {
+			t.Errorf("unexpected error: got %v want %v", err, errGateClosed)
+		}
+		if pinger.pings != 0 {
+			t.Errorf("unexpected pings: got %v want %v", pinger.pings, 0)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package readiness
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/response"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
+)
+
+// Gate tracks whether the data dependencies of the server are ready to serve requests
+type Gate struct {
+	ready int32
+}
+
+// SetReady opens or closes the gate
+func (g *Gate) SetReady(ready bool) {
+	var value int32
+	if ready {
+		value = 1
+	}
+	atomic.StoreInt32(&g.ready, value)
+}
+
+// IsReady returns true when the gate is open
+func (g *Gate) IsReady() bool {
+	return atomic.LoadInt32(&g.ready) == 1
+}
+
+// NewHandlerFunc creates a middleware which responds with 503 while the gate is closed
+func NewHandlerFunc(logger zerolog.Logger, render renderer.Renderer, gate *Gate) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !gate.IsReady() {
+				logger.Debug().Caller().Msg("request rejected, server is not ready")
+				response.WriteError(logger, render, w, http.StatusServiceUnavailable, "Service unavailable, try again later")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
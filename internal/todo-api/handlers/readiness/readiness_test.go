@@ -0,0 +1,45 @@
+package readiness
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/render"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	gate := &Gate{}
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := NewHandlerFunc(zerolog.New(os.Stdout), render.New(), gate)(okHandler)
+
+	t.Run("notReady", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/todo/1", nil))
+
+		if status := rr.Code; status != http.StatusServiceUnavailable {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusServiceUnavailable)
+			t.FailNow()
+		}
+
+		expected := `{"message":"Service unavailable, try again later"}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+	})
+
+	t.Run("ready", func(t *testing.T) {
+		gate.SetReady(true)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/todo/1", nil))
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package readonly
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/response"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
+)
+
+// Mode is the read-only state of the server, it can be toggled while the server is running
+type Mode struct {
+	enabled int32
+}
+
+// SetEnabled enables or disables read-only mode
+func (m *Mode) SetEnabled(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&m.enabled, value)
+}
+
+// IsEnabled returns true when the server is in read-only mode
+func (m *Mode) IsEnabled() bool {
+	return atomic.LoadInt32(&m.enabled) == 1
+}
+
+// NewHandlerFunc creates a middleware which responds to write requests with 503 while read-only mode is enabled, reads
+// are passed through
+func NewHandlerFunc(logger zerolog.Logger, render renderer.Renderer, mode *Mode) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if mode.IsEnabled() && isWrite(r.Method) {
+				logger.Debug().Caller().Str("verb", r.Method).Msg("write rejected in read-only mode")
+				response.WriteError(logger, render, w, http.StatusServiceUnavailable, "Service is in read-only mode, writes are temporarily disabled")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
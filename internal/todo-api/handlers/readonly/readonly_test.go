@@ -0,0 +1,54 @@
+package readonly
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/render"
+)
+
+func TestReadOnlyHandler(t *testing.T) {
+	mode := &Mode{}
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := NewHandlerFunc(zerolog.New(os.Stdout), render.New(), mode)(okHandler)
+
+	t.Run("disabled", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("POST", "/api/todo/", nil))
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+		}
+	})
+
+	t.Run("writesBlocked", func(t *testing.T) {
+		mode.SetEnabled(true)
+
+		for _, method := range []string{"POST", "PUT", "PATCH", "DELETE"} {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(method, "/api/todo/1", nil))
+
+			if status := rr.Code; status != http.StatusServiceUnavailable {
+				t.Errorf("unexpected status code for %v: got %v want %v", method, status, http.StatusServiceUnavailable)
+			}
+		}
+	})
+
+	t.Run("readsSucceed", func(t *testing.T) {
+		mode.SetEnabled(true)
+
+		for _, path := range []string{"/api/todo/1", "/api/health"} {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("unexpected status code for %v: got %v want %v", path, status, http.StatusOK)
+			}
+		}
+	})
+}
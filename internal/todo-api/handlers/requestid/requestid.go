@@ -0,0 +1,61 @@
+package requestid
+
+import (
+	"net/http"
+
+	"github.com/rs/xid"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+const (
+	defaultInboundHeader  = "X-Request-Id"
+	defaultOutboundHeader = "Request-Id"
+
+	// maxIDLength bounds ids taken from clients so they can't flood the logs
+	maxIDLength = 128
+)
+
+// NewHandlerFunc creates a middleware which carries a request id in the request ctx. The id is taken from the first of
+// the inbound headers which has a valid value, otherwise one is generated. It's echoed in the outbound response header
+// so callers can correlate their logs with ours. Empty inbound or outbound names fall back to X-Request-Id and
+// Request-Id.
+func NewHandlerFunc(inbound []string, outbound string) func(http.Handler) http.Handler {
+	if len(inbound) == 0 {
+		inbound = []string{defaultInboundHeader}
+	}
+	if outbound == "" {
+		outbound = defaultOutboundHeader
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := ""
+			for _, name := range inbound {
+				if value := r.Header.Get(name); validID(value) {
+					id = value
+					break
+				}
+			}
+			if id == "" {
+				id = xid.New().String()
+			}
+
+			w.Header().Set(outbound, id)
+			next.ServeHTTP(w, r.WithContext(utils.WithRequestID(r.Context(), id)))
+		})
+	}
+}
+
+// validID reports whether an id is non-empty, bounded and only contains printable ASCII, so it's safe to log and echo
+func validID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+func TestRequestIDHandler(t *testing.T) {
+	var ctxID string
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxID, _ = utils.RequestID(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("customInboundHeader", func(t *testing.T) {
+		handler := NewHandlerFunc([]string{"X-Request-Id", "X-Correlation-Id"}, "X-Correlation-Id")(okHandler)
+		req := httptest.NewRequest("GET", "/api/todo/1", nil)
+		req.Header.Set("X-Correlation-Id", "abc-123")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if ctxID != "abc-123" {
+			t.Errorf("unexpected ctx request id: got %v want %v", ctxID, "abc-123")
+		}
+		if id := rr.Header().Get("X-Correlation-Id"); id != "abc-123" {
+			t.Errorf("unexpected X-Correlation-Id: got %v want %v", id, "abc-123")
+		}
+		if id := rr.Header().Get("Request-Id"); id != "" {
+			t.Errorf("unexpected Request-Id: %v", id)
+		}
+	})
+
+	t.Run("firstMatchingHeaderWins", func(t *testing.T) {
+		handler := NewHandlerFunc([]string{"X-Request-Id", "X-Correlation-Id"}, "")(okHandler)
+		req := httptest.NewRequest("GET", "/api/todo/1", nil)
+		req.Header.Set("X-Request-Id", "first")
+		req.Header.Set("X-Correlation-Id", "second")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if id := rr.Header().Get("Request-Id"); id != "first" {
+			t.Errorf("unexpected Request-Id: got %v want %v", id, "first")
+		}
+	})
+
+	t.Run("generatedWithoutMatch", func(t *testing.T) {
+		handler := NewHandlerFunc([]string{"X-Correlation-Id"}, "")(okHandler)
+		req := httptest.NewRequest("GET", "/api/todo/1", nil)
+		req.Header.Set("X-Request-Id", "ignored")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		id := rr.Header().Get("Request-Id")
+		if id == "" || id == "ignored" || id != ctxID {
+			t.Errorf("expected a generated request id: got %v ctx %v", id, ctxID)
+		}
+	})
+
+	t.Run("invalidInboundIgnored", func(t *testing.T) {
+		handler := NewHandlerFunc(nil, "")(okHandler)
+		req := httptest.NewRequest("GET", "/api/todo/1", nil)
+		req.Header.Set("X-Request-Id", strings.Repeat("a", maxIDLength+1))
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if id := rr.Header().Get("Request-Id"); id == "" || len(id) > maxIDLength {
+			t.Errorf("expected a generated request id: got %v", id)
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package response
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
+)
+
+// WriteError responds with the status code and a models.Error carrying the message, it's shared by the middleware so
+// every error response has the same shape
+func WriteError(logger zerolog.Logger, render renderer.Renderer, w http.ResponseWriter, statusCode int, message string) {
+	WriteErrorCode(logger, render, w, statusCode, "", message)
+}
+
+// WriteErrorCode is WriteError with a machine readable code, e.g. `unauthenticated`
+func WriteErrorCode(logger zerolog.Logger, render renderer.Renderer, w http.ResponseWriter, statusCode int, code, message string) {
+	if rErr := render.JSON(w, statusCode, models.Error{
+		Code:    code,
+		Message: message,
+	}); rErr != nil {
+		logger.Error().Caller().Err(rErr).Msg("failed to marshal json response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
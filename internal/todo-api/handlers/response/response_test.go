@@ -0,0 +1,35 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/render"
+)
+
+func TestWriteError(t *testing.T) {
+	cases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{"withoutCode", "", `{"message":"invalid body"}`},
+		{"withCode", "forbidden", `{"code":"forbidden","message":"invalid body"}`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			WriteErrorCode(zerolog.New(os.Stdout), render.New(), rr, http.StatusBadRequest, c.code, "invalid body")
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+			}
+			if rr.Body.String() != c.expected {
+				t.Errorf("unexpected body: got %v want %v", rr.Body.String(), c.expected)
+			}
+		})
+	}
+}
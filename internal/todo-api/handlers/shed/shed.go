@@ -0,0 +1,70 @@
+package shed
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/response"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
+)
+
+const (
+	lowPriority  = "low"
+	highPriority = "high"
+
+	retryAfter = "1"
+)
+
+var (
+	inFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "todo_api_requests_in_flight",
+		Help: "Number of API requests currently being served.",
+	})
+	shedDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "todo_api_requests_shed_total",
+		Help: "Number of API requests rejected by load shedding, by priority.",
+	}, []string{"priority"})
+)
+
+// NewHandlerFunc creates a middleware which tracks the number of in-flight requests and, once more than threshold are
+// in flight, sheds low priority requests with 503 so writes keep being served under spiky traffic. Reads are low
+// priority since they're cheap for clients to retry, including long polling for changes. A threshold of 0 or less only
+// tracks the in-flight count.
+func NewHandlerFunc(logger zerolog.Logger, render renderer.Renderer, threshold int) func(http.Handler) http.Handler {
+	var current int64
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt64(&current, 1)
+			inFlight.Inc()
+			defer func() {
+				atomic.AddInt64(&current, -1)
+				inFlight.Dec()
+			}()
+
+			if threshold > 0 && n > int64(threshold) && priority(r.Method) == lowPriority {
+				shedDecisions.WithLabelValues(lowPriority).Inc()
+				logger.Warn().Caller().Int64("in_flight", n).Str("verb", r.Method).Msg("request shed under load")
+
+				w.Header().Set("Retry-After", retryAfter)
+				response.WriteError(logger, render, w, http.StatusServiceUnavailable, "Service is under heavy load, try again later")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func priority(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return lowPriority
+	default:
+		return highPriority
+	}
+}
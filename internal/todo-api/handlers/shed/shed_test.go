@@ -0,0 +1,70 @@
+package shed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/unrolled/render"
+)
+
+func TestShedHandler(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	blockingHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/todo/changes" {
+			entered <- struct{}{}
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("belowThreshold", func(t *testing.T) {
+		handler := NewHandlerFunc(zerolog.New(os.Stdout), render.New(), 1)(blockingHandler)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/todo/1", nil))
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+		}
+	})
+
+	t.Run("shedsReadsAboveThreshold", func(t *testing.T) {
+		handler := NewHandlerFunc(zerolog.New(os.Stdout), render.New(), 1)(blockingHandler)
+		done := make(chan struct{})
+		go func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/todo/changes", nil))
+			close(done)
+		}()
+		<-entered
+		defer func() {
+			close(release)
+			<-done
+		}()
+
+		before := testutil.ToFloat64(shedDecisions.WithLabelValues(lowPriority))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/todo/1", nil))
+
+		if status := rr.Code; status != http.StatusServiceUnavailable {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusServiceUnavailable)
+			t.FailNow()
+		}
+		if retry := rr.Header().Get("Retry-After"); retry != retryAfter {
+			t.Errorf("unexpected Retry-After: got %v want %v", retry, retryAfter)
+		}
+		if shed := testutil.ToFloat64(shedDecisions.WithLabelValues(lowPriority)); shed != before+1 {
+			t.Errorf("unexpected shed count: got %v want %v", shed, before+1)
+		}
+
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("POST", "/api/todo/", nil))
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code for write: got %v want %v", status, http.StatusOK)
+		}
+	})
+}
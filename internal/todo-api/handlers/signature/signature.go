@@ -0,0 +1,79 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/auth"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/response"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
+)
+
+const (
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the request
+	SignatureHeader = "X-Signature"
+	// TimestampHeader carries the unix time in seconds the request was signed at
+	TimestampHeader = "X-Signature-Timestamp"
+
+	challenge = `HMAC-SHA256 headers="X-Signature-Timestamp X-Signature"`
+)
+
+// Sign returns the hex encoded HMAC-SHA256 over the method, request URI, timestamp and body, each separated by a
+// newline. The request URI is the path with the query string as sent, e.g. `/api/todo/changes?since=...`.
+func Sign(secret []byte, method, requestURI, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(fmt.Sprint(method, "\n", requestURI, "\n", timestamp, "\n")))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewHandlerFunc creates a middleware which responds with 401 unless the request carries a valid signature signed within
+// maxSkew of the current time, the timestamp check protects against replayed requests. The query string is signed so
+// it can't be changed on a replay within the skew, the body is signed as the handler receives it, i.e. decompressed.
+func NewHandlerFunc(logger zerolog.Logger, render renderer.Renderer, secret string, maxSkew time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timestamp := r.Header.Get(TimestampHeader)
+			signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				logger.Debug().Caller().Err(err).Msg("missing or invalid signature timestamp")
+				auth.WriteUnauthenticated(logger, render, w, challenge, "invalid signature timestamp")
+				return
+			}
+			if skew := time.Since(time.Unix(signedAt, 0)); skew > maxSkew || skew < -maxSkew {
+				logger.Debug().Caller().Dur("skew", skew).Msg("stale signature timestamp")
+				auth.WriteUnauthenticated(logger, render, w, challenge, "stale signature timestamp")
+				return
+			}
+
+			var body []byte
+			if r.Body != nil {
+				body, err = ioutil.ReadAll(r.Body)
+				if err != nil {
+					logger.Error().Caller().Err(err).Msg("failed to read body for signature")
+					response.WriteError(logger, render, w, http.StatusBadRequest, "invalid body")
+					return
+				}
+				r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			}
+
+			expected := Sign([]byte(secret), r.Method, r.URL.RequestURI(), timestamp, body)
+			if !hmac.Equal([]byte(expected), []byte(r.Header.Get(SignatureHeader))) {
+				logger.Debug().Caller().Msg("invalid request signature")
+				auth.WriteUnauthenticated(logger, render, w, challenge, "invalid signature")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package signature
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/render"
+)
+
+const testSecret = "secret"
+
+func initSignatureHandler() http.Handler {
+	echoHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	})
+	return NewHandlerFunc(zerolog.New(os.Stdout), render.New(), testSecret, time.Minute)(echoHandler)
+}
+
+func newSignedRequest(t *testing.T, body string, signedAt time.Time) *http.Request {
+	req, err := http.NewRequest("POST", "/api/todo/", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	timestamp := fmt.Sprint(signedAt.Unix())
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign([]byte(testSecret), "POST", "/api/todo/", timestamp, []byte(body)))
+	return req
+}
+
+func TestSignatureHandler(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		initSignatureHandler().ServeHTTP(rr, newSignedRequest(t, `{"todo":"test"}`, time.Now()))
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+		if rr.Body.String() != `{"todo":"test"}` {
+			t.Errorf("unexpected body passed on: got %v want %v", rr.Body.String(), `{"todo":"test"}`)
+		}
+	})
+
+	t.Run("tamperedQuery", func(t *testing.T) {
+		timestamp := fmt.Sprint(time.Now().Unix())
+		signed := Sign([]byte(testSecret), "GET", "/api/todo/changes?since=2020-06-01T00:00:00Z", timestamp, nil)
+
+		cases := map[string]int{
+			"/api/todo/changes?since=2020-06-01T00:00:00Z":         http.StatusOK,
+			"/api/todo/changes?since=2000-01-01T00:00:00Z":         http.StatusUnauthorized,
+			"/api/todo/changes?since=2020-06-01T00:00:00Z&wait=1h": http.StatusUnauthorized,
+		}
+		for target, expected := range cases {
+			req := httptest.NewRequest("GET", target, nil)
+			req.Header.Set(TimestampHeader, timestamp)
+			req.Header.Set(SignatureHeader, signed)
+
+			rr := httptest.NewRecorder()
+			initSignatureHandler().ServeHTTP(rr, req)
+
+			if status := rr.Code; status != expected {
+				t.Errorf("unexpected status code for %v: got %v want %v", target, status, expected)
+			}
+		}
+	})
+
+	t.Run("staleTimestamp", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		initSignatureHandler().ServeHTTP(rr, newSignedRequest(t, `{"todo":"test"}`, time.Now().Add(-5*time.Minute)))
+
+		if status := rr.Code; status != http.StatusUnauthorized {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusUnauthorized)
+			t.FailNow()
+		}
+
+		expected := `{"code":"unauthenticated","message":"stale signature timestamp"}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+	})
+
+	t.Run("tamperedBody", func(t *testing.T) {
+		req := newSignedRequest(t, `{"todo":"test"}`, time.Now())
+		req.Body = ioutil.NopCloser(strings.NewReader(`{"todo":"tampered"}`))
+
+		rr := httptest.NewRecorder()
+		initSignatureHandler().ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusUnauthorized {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusUnauthorized)
+			t.FailNow()
+		}
+
+		expected := `{"code":"unauthenticated","message":"invalid signature"}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+	})
+
+	t.Run("missingTimestamp", func(t *testing.T) {
+		req := newSignedRequest(t, `{"todo":"test"}`, time.Now())
+		req.Header.Del(TimestampHeader)
+
+		rr := httptest.NewRecorder()
+		initSignatureHandler().ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusUnauthorized {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusUnauthorized)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package strict
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/response"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
+)
+
+// NewHandlerFunc creates a middleware which rejects ambiguous requests when strict mode is enabled: duplicate query
+// parameters return 400 and writes without a Content-Type return 415
+func NewHandlerFunc(logger zerolog.Logger, render renderer.Renderer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for key, values := range r.URL.Query() {
+				if len(values) > 1 {
+					logger.Debug().Caller().Str("param", key).Msg("duplicate query parameter in strict mode")
+					response.WriteError(logger, render, w, http.StatusBadRequest, fmt.Sprint("duplicate query parameter: ", key))
+					return
+				}
+			}
+
+			if isWrite(r.Method) && r.Header.Get("Content-Type") == "" {
+				logger.Debug().Caller().Str("verb", r.Method).Msg("missing content type in strict mode")
+				response.WriteError(logger, render, w, http.StatusUnsupportedMediaType, "missing Content-Type header")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isWrite(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
@@ -0,0 +1,84 @@
+package strict
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/render"
+)
+
+func initStrictHandler() http.Handler {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return NewHandlerFunc(zerolog.New(os.Stdout), render.New())(okHandler)
+}
+
+func TestStrictHandler(t *testing.T) {
+	t.Run("validRequest", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/api/todo/?a=1&b=2", strings.NewReader(`{"todo":"test"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		initStrictHandler().ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+		}
+	})
+
+	t.Run("duplicateQueryParam", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/todo/1?a=1&a=2", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		initStrictHandler().ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+			t.FailNow()
+		}
+
+		expected := `{"message":"duplicate query parameter: a"}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+	})
+
+	t.Run("missingContentType", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/api/todo/", strings.NewReader(`{"todo":"test"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		initStrictHandler().ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusUnsupportedMediaType {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusUnsupportedMediaType)
+		}
+	})
+
+	t.Run("readWithoutContentType", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/todo/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		initStrictHandler().ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package todo
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// writeDeduper coalesces identical todo creates, concurrent requests share one insert and repeats within the window
+// get the same result. A nil writeDeduper disables coalescing.
+type writeDeduper struct {
+	window time.Duration
+	group  singleflight.Group
+
+	mu     sync.Mutex
+	recent map[string]recentWrite
+}
+
+type recentWrite struct {
+	item      models.TodoItem
+	createdAt time.Time
+}
+
+func newWriteDeduper(window time.Duration) *writeDeduper {
+	if window <= 0 {
+		return nil
+	}
+	return &writeDeduper{
+		window: window,
+		recent: map[string]recentWrite{},
+	}
+}
+
+// do runs create once per normalized todo text, shared is true when this request didn't insert the result, either
+// because a concurrent request did or it was recently created
+func (d *writeDeduper) do(todoText string, create func() (models.TodoItem, error)) (item models.TodoItem, shared bool, err error) {
+	if d == nil {
+		item, err = create()
+		return item, false, err
+	}
+
+	key := strings.Join(strings.Fields(todoText), " ")
+	// only the caller whose function runs in the group can set created, singleflight reports every caller as shared
+	created := false
+	result, err, _ := d.group.Do(key, func() (interface{}, error) {
+		// checked inside the group so a create finishing between the check and the call can't be repeated
+		if recentItem, ok := d.lookup(key); ok {
+			return recentItem, nil
+		}
+
+		newItem, err := create()
+		if err != nil {
+			return nil, err
+		}
+		created = true
+		d.store(key, newItem)
+		return newItem, nil
+	})
+	if err != nil {
+		return models.TodoItem{}, false, err
+	}
+
+	return result.(models.TodoItem), !created, nil
+}
+
+// forget drops the recent create of the todo with id, e.g. once it's deleted so a repeat creates it again instead of
+// returning a todo which no longer exists
+func (d *writeDeduper) forget(id int) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for k, write := range d.recent {
+		if write.item.ID == id {
+			delete(d.recent, k)
+		}
+	}
+}
+
+func (d *writeDeduper) lookup(key string) (models.TodoItem, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for k, write := range d.recent {
+		if now.Sub(write.createdAt) >= d.window {
+			delete(d.recent, k)
+		}
+	}
+
+	write, ok := d.recent[key]
+	return write.item, ok
+}
+
+func (d *writeDeduper) store(key string, item models.TodoItem) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.recent[key] = recentWrite{
+		item:      item,
+		createdAt: time.Now(),
+	}
+}
@@ -0,0 +1,47 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+var validationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "todo_validation_failures_total",
+	Help: "Number of requests which failed validation, by handler and field.",
+}, []string{"handler", "field"})
+
+// recordValidationFailure emits a structured event for the request fields which failed validation and increments the
+// per-field counter
+func recordValidationFailure(ctx context.Context, handler string, fields []string, err error) {
+	for _, field := range fields {
+		validationFailures.WithLabelValues(handler, field).Inc()
+	}
+
+	log.Ctx(ctx).Debug().
+		Str("handler", handler).
+		Strs("fields", fields).
+		Err(err).
+		Msg("request failed validation")
+}
+
+// validationFields returns the sorted names of the fields in an ozzo validation error
+func validationFields(err error) []string {
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+
+	fields := make([]string, 0, len(errs))
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
@@ -0,0 +1,32 @@
+package todo
+
+import "sync"
+
+// changeNotifier wakes long-poll waiters whenever a todo changes
+type changeNotifier struct {
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+func newChangeNotifier() *changeNotifier {
+	return &changeNotifier{
+		changed: make(chan struct{}),
+	}
+}
+
+// wait returns a channel which is closed on the next change
+func (n *changeNotifier) wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.changed
+}
+
+// notify wakes every current waiter
+func (n *changeNotifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	close(n.changed)
+	n.changed = make(chan struct{})
+}
@@ -0,0 +1,177 @@
+package todo
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/rs/zerolog/log"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+// defaultShareTTL is how long a share link is valid when no TTL is configured
+const defaultShareTTL = 24 * time.Hour
+
+// errInvalidShareToken is returned for share tokens which are malformed, have a bad signature or have expired
+var errInvalidShareToken = errors.New("invalid share token")
+
+// Share mints a signed, expiring, read-only link for a single todo. Tokens carry the todo id, its share version and the
+// expiry and are signed with the share secret, so RevokeShares revokes the links of one todo and rotating the secret
+// revokes every outstanding link
+func (h *Handler) Share(w http.ResponseWriter, r *http.Request) {
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	todoID, err := utils.URLParamInt(r, "id")
+	if err != nil {
+		recordValidationFailure(logCtx, "share", []string{"id"}, err)
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logCtx = utils.GetSubLoggerCtx(h.logger, utils.WithTodoID(logCtx, todoID))
+
+	todoResult, found, err := h.store.GetTodo(logCtx, todoID)
+	if h.writePoolExhausted(logCtx, w, err) {
+		return
+	}
+	if err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to get todoItem to share")
+		h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
+		return
+	}
+	if !found {
+		h.writeErrorResponse(logCtx, w, http.StatusNotFound, "Todo not found")
+		return
+	}
+
+	ttl := h.shareTTL
+	if ttl <= 0 {
+		ttl = defaultShareTTL
+	}
+	expiresOn := h.now().Add(ttl).UTC().Truncate(time.Second)
+	token := signShareToken(h.shareSecret, todoID, todoResult.ShareVersion, expiresOn)
+
+	err = h.renderJSON(logCtx, w, http.StatusCreated, models.ShareResponse{
+		Token:     token,
+		URL:       "/shared/" + token,
+		ExpiresOn: expiresOn,
+	})
+	if err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to marshal json todo share response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// RevokeShares revokes every share link minted for a todo so far, links minted afterwards resolve again
+func (h *Handler) RevokeShares(w http.ResponseWriter, r *http.Request) {
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	todoID, err := utils.URLParamInt(r, "id")
+	if err != nil {
+		recordValidationFailure(logCtx, "revoke_shares", []string{"id"}, err)
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logCtx = utils.GetSubLoggerCtx(h.logger, utils.WithTodoID(logCtx, todoID))
+
+	count, err := h.store.RevokeShares(logCtx, todoID)
+	if h.writePoolExhausted(logCtx, w, err) {
+		return
+	}
+	if err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to revoke todo shares")
+		h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
+		return
+	}
+	if count == 0 {
+		h.writeErrorResponse(logCtx, w, http.StatusNotFound, "Todo not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Shared resolves a share token to its todo without requiring auth, invalid, expired and revoked tokens are treated the
+// same as a missing todo so they don't reveal which todos exist
+func (h *Handler) Shared(w http.ResponseWriter, r *http.Request) {
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	todoID, shareVersion, err := verifyShareToken(h.shareSecret, chi.URLParam(r, "token"), h.now())
+	if err != nil {
+		log.Ctx(logCtx).Debug().Caller().Err(err).Msg("share token rejected")
+		h.writeErrorResponse(logCtx, w, http.StatusNotFound, "Shared todo not found or the link has expired")
+		return
+	}
+
+	logCtx = utils.GetSubLoggerCtx(h.logger, utils.WithTodoID(logCtx, todoID))
+
+	todoResult, found, err := h.store.GetTodo(logCtx, todoID)
+	if h.writePoolExhausted(logCtx, w, err) {
+		return
+	}
+	if err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to get shared todoItem")
+		h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
+		return
+	}
+	if !found || todoResult.ShareVersion != shareVersion {
+		h.writeErrorResponse(logCtx, w, http.StatusNotFound, "Shared todo not found or the link has expired")
+		return
+	}
+
+	err = h.renderTodos(logCtx, w, http.StatusOK, todoResult)
+	if err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to marshal json shared todo response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// signShareToken returns a token of the form <id>.<share version>.<expiry unix seconds>.<hex HMAC-SHA256 of the rest>
+func signShareToken(secret string, id, shareVersion int, expiresOn time.Time) string {
+	payload := fmt.Sprintf("%d.%d.%d", id, shareVersion, expiresOn.Unix())
+	return payload + "." + shareSignature(secret, payload)
+}
+
+// verifyShareToken returns the todo id and share version of a token signed with secret which hasn't expired at now
+func verifyShareToken(secret, token string, now time.Time) (int, int, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return 0, 0, errInvalidShareToken
+	}
+
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(shareSignature(secret, payload))) {
+		return 0, 0, errInvalidShareToken
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, errInvalidShareToken
+	}
+	shareVersion, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, errInvalidShareToken
+	}
+	expiry, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || now.After(time.Unix(expiry, 0)) {
+		return 0, 0, errInvalidShareToken
+	}
+
+	return id, shareVersion, nil
+}
+
+func shareSignature(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,139 @@
+package todo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Modes for serializing a zero created_on or updated_on, which likely means a partially populated record
+const (
+	// ZeroTimestampsKeep sends them as 0001-01-01T00:00:00Z
+	ZeroTimestampsKeep = "keep"
+	// ZeroTimestampsNull sends them as null
+	ZeroTimestampsNull = "null"
+	// ZeroTimestampsOmit leaves them out of the response
+	ZeroTimestampsOmit = "omit"
+)
+
+// timestampFields are the json names of the todo timestamps
+var timestampFields = map[string]bool{
+	"created_on": true,
+	"updated_on": true,
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// ValidateZeroTimestamps returns an error for an unsupported zero timestamps mode, empty is the same as keep
+func ValidateZeroTimestamps(mode string) error {
+	switch mode {
+	case "", ZeroTimestampsKeep, ZeroTimestampsNull, ZeroTimestampsOmit:
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("unsupported ZeroTimestamps mode: %s", mode))
+	}
+}
+
+// renderTodos renders a response containing todos, logging a warning for zero timestamps and replacing them as
+// configured
+func (h *Handler) renderTodos(ctx context.Context, w http.ResponseWriter, statusCode int, v interface{}) error {
+	if !hasZeroTimestamp(reflect.ValueOf(v)) {
+		return h.renderJSON(ctx, w, statusCode, v)
+	}
+
+	log.Ctx(ctx).Warn().Caller().Msg("todo response has a zero timestamp, the record may be partially populated")
+	if h.zeroTimestamps != ZeroTimestampsNull && h.zeroTimestamps != ZeroTimestampsOmit {
+		return h.renderJSON(ctx, w, statusCode, v)
+	}
+
+	replaced, err := replaceZeroTimestamps(v, h.zeroTimestamps == ZeroTimestampsOmit)
+	if err != nil {
+		return err
+	}
+	return h.renderJSON(ctx, w, statusCode, replaced)
+}
+
+// hasZeroTimestamp reports whether v contains a zero time.Time in one of the timestampFields
+func hasZeroTimestamp(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return !v.IsNil() && hasZeroTimestamp(v.Elem())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if hasZeroTimestamp(v.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.Type == timeType {
+				name := jsonName(field)
+				if timestampFields[name] && v.Field(i).Interface().(time.Time).IsZero() {
+					return true
+				}
+				continue
+			}
+			if (field.Anonymous || field.PkgPath == "") && hasZeroTimestamp(v.Field(i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if i := bytes.IndexByte([]byte(tag), ','); i >= 0 {
+		tag = tag[:i]
+	}
+	return tag
+}
+
+// replaceZeroTimestamps re-encodes v with zero timestamps set to null or omitted. It decodes numbers as json.Number so
+// ids keep their precision, object keys come out sorted.
+func replaceZeroTimestamps(v interface{}, omit bool) (interface{}, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	zero, _ := time.Time{}.MarshalText()
+	replaceZeroTimestampValues(decoded, string(zero), omit)
+	return decoded, nil
+}
+
+func replaceZeroTimestampValues(v interface{}, zero string, omit bool) {
+	switch value := v.(type) {
+	case []interface{}:
+		for _, element := range value {
+			replaceZeroTimestampValues(element, zero, omit)
+		}
+	case map[string]interface{}:
+		for key, element := range value {
+			if timestampFields[key] && element == zero {
+				if omit {
+					delete(value, key)
+				} else {
+					value[key] = nil
+				}
+				continue
+			}
+			replaceZeroTimestampValues(element, zero, omit)
+		}
+	}
+}
@@ -1,65 +1,154 @@
 package todo
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 	"time"
+	"unicode/utf8"
 
-	"github.com/go-chi/chi"
-	validation "github.com/go-ozzo/ozzo-validation/v4"
-	"github.com/go-ozzo/ozzo-validation/v4/is"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
-	"github.com/unrolled/render"
 
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
 )
 
+const (
+	// defaultMaxChangesWait caps how long a changes request is held open when no cap is configured
+	defaultMaxChangesWait = 25 * time.Second
+	// changesWaitMargin is left between the longest changes wait and the router timeout to query and respond
+	changesWaitMargin = 5 * time.Second
+	// maxChangesLimit caps the number of todos returned by a changes request
+	maxChangesLimit = 100
+	// poolExhaustedRetryAfter is the Retry-After in seconds sent when the store's connection pool is exhausted
+	poolExhaustedRetryAfter = "1"
+)
+
+// errInvalidUTF8 is returned for bodies with invalid UTF-8, encoding/json would otherwise silently replace the bytes
+var errInvalidUTF8 = errors.New("body must be valid UTF-8")
+
 type Handler struct {
 	logger zerolog.Logger
 
-	render *render.Render
-	store  todo.TodoStore
+	render    renderer.Renderer
+	store     todo.TodoStore
+	validator TodoValidator
+	events    *events.Bus
+	notifier  *changeNotifier
+	deduper   *writeDeduper
+	// now is the clock used for created_on and computed ages
+	now func() time.Time
+
+	strictMode        bool
+	databaseCreatedOn bool
+	shareSecret       string
+	shareTTL          time.Duration
+	zeroTimestamps    string
+	postCreated       bool
+	maxChangesWait    time.Duration
+}
+
+// Options configure the optional behaviour of the handler
+type Options struct {
+	// StrictMode rejects unknown fields and trailing data in request bodies
+	StrictMode bool
+	// DedupeWindow coalesces identical creates into one insert when it's positive
+	DedupeWindow time.Duration
+	// DatabaseCreatedOn leaves created_on to the column default so it's assigned by the database clock
+	DatabaseCreatedOn bool
+	// ShareSecret signs share links, ShareTTL is how long they're valid
+	ShareSecret string
+	ShareTTL    time.Duration
+	// ZeroTimestamps is how zero timestamps are serialized, one of the ZeroTimestamps modes
+	ZeroTimestamps string
+	// PostCreated responds to creates with 201 and a Location header instead of 200
+	PostCreated bool
+	// MaxChangesWait caps how long a changes request is held open, see MaxChangesWait
+	MaxChangesWait time.Duration
 }
 
-// Creates TodoItem handler
-func NewHandler(logger zerolog.Logger, render *render.Render, store todo.Store) Handler {
-	return Handler{
+// MaxChangesWait returns the longest wait of a changes request which still responds within the router timeout, it
+// leaves changesWaitMargin for the query and the response or half of short timeouts
+func MaxChangesWait(routerTimeout time.Duration) time.Duration {
+	if routerTimeout <= 2*changesWaitMargin {
+		return routerTimeout / 2
+	}
+	return routerTimeout - changesWaitMargin
+}
+
+// Creates TodoItem handler, successful mutations are published to the bus
+func NewHandler(logger zerolog.Logger, render renderer.Renderer, store todo.Store, validator TodoValidator, bus *events.Bus,
+	opts Options) Handler {
+	h := Handler{
 		logger: logger,
 
-		render: render,
-		store:  &store,
+		render:    render,
+		store:     &store,
+		validator: validator,
+		events:    bus,
+		notifier:  newChangeNotifier(),
+		deduper:   newWriteDeduper(opts.DedupeWindow),
+		now:       time.Now,
+
+		strictMode:        opts.StrictMode,
+		databaseCreatedOn: opts.DatabaseCreatedOn,
+		shareSecret:       opts.ShareSecret,
+		shareTTL:          opts.ShareTTL,
+		zeroTimestamps:    opts.ZeroTimestamps,
+		postCreated:       opts.PostCreated,
+		maxChangesWait:    opts.MaxChangesWait,
 	}
+	if h.maxChangesWait <= 0 {
+		h.maxChangesWait = defaultMaxChangesWait
+	}
+	h.subscribe()
+	return h
+}
+
+// subscribe registers the handler's own side effects on the bus
+func (h *Handler) subscribe() {
+	h.events.Subscribe(func(event events.Event) {
+		switch event.(type) {
+		case events.TodoCreated, events.TodoUpdated:
+			h.notifier.notify()
+		}
+	})
 }
 
 // Handle HTTP Get for TodoItem
 func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
-	todoIDStr := chi.URLParam(r, "id")
-	err := validation.Validate(todoIDStr, validation.Required, is.Int.Error("id must be an integer"))
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	todoID, err := utils.URLParamInt(r, "id")
 	if err != nil {
-		h.logger.Debug().Caller().Msg("missing id in request")
-		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, err.Error())
+		recordValidationFailure(logCtx, "get", []string{"id"}, err)
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	todoID, err := strconv.Atoi(todoIDStr)
+	includeAge, err := parseIncludeAge(r)
 	if err != nil {
-		h.logger.Error().Caller().Err(err).Msg("failed to decode todoID")
-		h.writeErrorResponse(r.Context(), w, http.StatusInternalServerError, "Error decoding id value")
+		recordValidationFailure(logCtx, "get", []string{"include_age"}, err)
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	ctx := context.WithValue(r.Context(), "id", todoID)
-	logCtx := utils.GetSubLoggerCtx(h.logger, ctx)
+	logCtx = utils.GetSubLoggerCtx(h.logger, utils.WithTodoID(logCtx, todoID))
 
 	todoResult, found, err := h.store.GetTodo(logCtx, todoID)
+	if h.writePoolExhausted(logCtx, w, err) {
+		return
+	}
 	if err != nil {
 		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to get todoItem")
 		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, "Error retrieving record")
@@ -70,34 +159,82 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.render.JSON(w, http.StatusOK, todoResult)
+	var response interface{} = todoResult
+	if includeAge {
+		response = h.withAge(todoResult)
+	}
+	err = h.renderTodos(logCtx, w, http.StatusOK, response)
 	if err != nil {
-		log.Error().Caller().Err(err).Msg("failed to marshal json todo get response")
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to marshal json todo get response")
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
 
-// Handle HTTP Delete for TodoItem
-func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
-	todoIDStr := chi.URLParam(r, "id")
-	err := validation.Validate(todoIDStr, validation.Required, is.Int.Error("id must be an integer"))
+// Neighbors gets a todo with the ids of the previous and next todos in the sort order, `created_on` by default
+func (h *Handler) Neighbors(w http.ResponseWriter, r *http.Request) {
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	todoID, err := utils.URLParamInt(r, "id")
 	if err != nil {
-		h.logger.Debug().Caller().Msg("missing id in request")
-		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, err.Error())
+		recordValidationFailure(logCtx, "neighbors", []string{"id"}, err)
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	todoID, err := strconv.Atoi(todoIDStr)
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = "created_on"
+	}
+
+	logCtx = utils.GetSubLoggerCtx(h.logger, utils.WithTodoID(logCtx, todoID))
+
+	todoResult, found, err := h.store.GetTodoWithNeighbors(logCtx, todoID, sort)
+	if h.writePoolExhausted(logCtx, w, err) {
+		return
+	}
+	if errors.Is(err, todo.ErrInvalidSort) {
+		recordValidationFailure(logCtx, "neighbors", []string{"sort"}, err)
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
-		h.logger.Error().Caller().Err(err).Msg("failed to decode todoID")
-		h.writeErrorResponse(r.Context(), w, http.StatusInternalServerError, "Error decoding id value")
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to get todoItem with neighbors")
+		h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
+		return
+	}
+	if !found {
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	ctx := context.WithValue(r.Context(), "id", todoID)
-	logCtx := utils.GetSubLoggerCtx(h.logger, ctx)
+	if err = h.renderTodos(logCtx, w, http.StatusOK, todoResult); err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to marshal json todo neighbors response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// Handle HTTP Delete for TodoItem
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	todoID, err := utils.URLParamInt(r, "id")
+	if err != nil {
+		recordValidationFailure(logCtx, "delete", []string{"id"}, err)
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logCtx = utils.GetSubLoggerCtx(h.logger, utils.WithTodoID(logCtx, todoID))
 
 	count, err := h.store.DeleteTodo(logCtx, todoID)
+	if h.writePoolExhausted(logCtx, w, err) {
+		return
+	}
+	if status, message, ok := constraintErrorResponse(err); ok {
+		log.Ctx(logCtx).Debug().Caller().Err(err).Msg("todo delete rejected by constraint")
+		h.writeErrorResponse(logCtx, w, status, message)
+		return
+	}
 	if err != nil {
 		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to delete todo")
 		h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
@@ -108,45 +245,312 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	log.Ctx(logCtx).Debug().Caller().Msg(fmt.Sprint(count, " rows deleted for ", todoID))
+	h.deduper.forget(todoID)
+	h.events.Publish(events.TodoDeleted{ID: todoID})
 
 	w.WriteHeader(http.StatusOK)
 }
 
+// Touch bumps a todo's updated_on to now without changing anything else, e.g. to move it to the top of a recency
+// sorted list
+func (h *Handler) Touch(w http.ResponseWriter, r *http.Request) {
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	todoID, err := utils.URLParamInt(r, "id")
+	if err != nil {
+		recordValidationFailure(logCtx, "touch", []string{"id"}, err)
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logCtx = utils.GetSubLoggerCtx(h.logger, utils.WithTodoID(logCtx, todoID))
+
+	count, err := h.store.TouchTodo(logCtx, todoID)
+	if h.writePoolExhausted(logCtx, w, err) {
+		return
+	}
+	if err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to touch todo")
+		h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
+		return
+	}
+	if count == 0 {
+		h.writeErrorResponse(logCtx, w, http.StatusNotFound, "Todo not found")
+		return
+	}
+	h.events.Publish(events.TodoUpdated{ID: todoID})
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Attempts increments a todo's attempts counter and returns the new count, e.g. for a worker claiming a queued todo
+func (h *Handler) Attempts(w http.ResponseWriter, r *http.Request) {
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	todoID, err := utils.URLParamInt(r, "id")
+	if err != nil {
+		recordValidationFailure(logCtx, "attempts", []string{"id"}, err)
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logCtx = utils.GetSubLoggerCtx(h.logger, utils.WithTodoID(logCtx, todoID))
+
+	attempts, err := h.store.IncrementAttempts(logCtx, todoID)
+	if h.writePoolExhausted(logCtx, w, err) {
+		return
+	}
+	if errors.Is(err, todo.ErrNotFound) {
+		h.writeErrorResponse(logCtx, w, http.StatusNotFound, "Todo not found")
+		return
+	}
+	if err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to increment todo attempts")
+		h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
+		return
+	}
+	h.events.Publish(events.TodoUpdated{ID: todoID})
+
+	if err := h.renderJSON(logCtx, w, http.StatusOK, models.TodoAttemptsResponse{Attempts: attempts}); err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to marshal json attempts response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
 // Handle HTTP Post for TodoItem
 func (h *Handler) Post(w http.ResponseWriter, r *http.Request) {
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
 	var todoRequest models.TodoPostRequest
-	if err := unmarshalRequestBody(r, &todoRequest); err != nil {
-		h.logger.Error().Caller().Err(err).Msgf("failed to decode todo body: %v", todoRequest)
-		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, "invalid body")
+	if err := unmarshalRequestBody(r, &todoRequest, h.strictMode); err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msgf("failed to decode todo body: %v", todoRequest)
+		if err == errInvalidUTF8 {
+			h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, "invalid body")
 		return
 	}
 
 	if err := todoRequest.IsValid(); err != nil {
-		h.logger.Debug().Caller().Err(err).Msg("invalid post")
-		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, err.Error())
+		recordValidationFailure(logCtx, "post", validationFields(err), err)
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+	newTodo := models.TodoItem{
+		Todo: todoRequest.Todo,
+	}
+	if !h.databaseCreatedOn {
+		newTodo.CreatedOn = h.now()
+		newTodo.UpdatedOn = newTodo.CreatedOn
+	}
+	if err := h.validator.Validate(logCtx, newTodo); err != nil {
+		log.Ctx(logCtx).Debug().Caller().Err(err).Msg("todo rejected by validator")
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	id, err := h.store.PostTodo(logCtx, models.TodoItem{
-		Todo:      todoRequest.Todo,
-		CreatedOn: time.Now(),
-	})
+	var todoResult models.TodoItem
+	var shared bool
+	var err error
+	// If-None-Match: * only creates the todo when none with the same text exists, it isn't coalesced by the deduper
+	// since a repeat has to fail the precondition rather than return the first create
+	if _, wildcard := utils.ParseETags(r.Header.Get("If-None-Match")); wildcard {
+		var created bool
+		todoResult, created, err = h.store.PostTodoIfNotExists(logCtx, newTodo)
+		if err == nil && !created {
+			log.Ctx(logCtx).Debug().Caller().Msg("conditional todo create skipped, todo already exists")
+			h.writeErrorResponse(logCtx, w, http.StatusPreconditionFailed, "A todo with the same text already exists")
+			return
+		}
+	} else {
+		todoResult, shared, err = h.deduper.do(newTodo.Todo, func() (models.TodoItem, error) {
+			return h.store.PostTodo(logCtx, newTodo)
+		})
+	}
+	if h.writePoolExhausted(logCtx, w, err) {
+		return
+	}
+	if status, message, ok := constraintErrorResponse(err); ok {
+		log.Ctx(logCtx).Debug().Caller().Err(err).Msg("todo insert rejected by constraint")
+		h.writeErrorResponse(logCtx, w, status, message)
+		return
+	}
 	if err != nil {
 		log.Ctx(logCtx).Error().Caller().Err(err).Msgf("failed to insert todo record: %v", todoRequest)
 		h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
 		return
 	}
+	if todoResult.ID <= 0 {
+		log.Ctx(logCtx).Warn().Caller().Int("id", todoResult.ID).Msg("store returned an invalid id for created todo")
+		h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
+		return
+	}
+	// a coalesced create shares the row inserted by the first request, which already published its event
+	if shared {
+		log.Ctx(logCtx).Debug().Caller().Int("id", todoResult.ID).Msg("coalesced duplicate todo create")
+	} else {
+		h.events.Publish(events.TodoCreated{Item: todoResult})
+	}
 
-	if err = h.render.JSON(w, http.StatusOK, models.TodoPostResponse{ID: id}); err != nil {
+	status := http.StatusOK
+	if h.postCreated {
+		status = http.StatusCreated
+		w.Header().Set("Location", fmt.Sprintf("/api/todo/%d", todoResult.ID))
+	}
+	if err = h.renderTodos(logCtx, w, status, todoResult); err != nil {
 		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to marshal json response")
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
 
+// Handle HTTP Get for TodoItem's created or updated since a timestamp, if there are none it waits for up to `wait` for a
+// change
+func (h *Handler) Changes(w http.ResponseWriter, r *http.Request) {
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	since, err := time.Parse(time.RFC3339Nano, r.URL.Query().Get("since"))
+	if err != nil {
+		recordValidationFailure(logCtx, "changes", []string{"since"}, err)
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, "since must be an RFC3339 timestamp")
+		return
+	}
+
+	var wait time.Duration
+	if waitStr := r.URL.Query().Get("wait"); waitStr != "" {
+		wait, err = utils.ParseDuration("wait", waitStr, 0, h.maxChangesWait)
+		if err != nil {
+			recordValidationFailure(logCtx, "changes", []string{"wait"}, err)
+			h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	includeAge, err := parseIncludeAge(r)
+	if err != nil {
+		recordValidationFailure(logCtx, "changes", []string{"include_age"}, err)
+		h.writeErrorResponse(logCtx, w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+
+	for {
+		// subscribe before querying so a change between the query and the wait isn't missed
+		changed := h.notifier.wait()
+
+		todoResults, err := h.store.GetTodosSince(logCtx, since, maxChangesLimit)
+		if h.writePoolExhausted(logCtx, w, err) {
+			return
+		}
+		if err != nil {
+			log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to get todo changes")
+			h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
+			return
+		}
+		if len(todoResults) > 0 || wait == 0 {
+			h.writeChangesResponse(logCtx, w, todoResults, includeAge)
+			return
+		}
+
+		select {
+		case <-changed:
+			continue
+		case <-timeout.C:
+			h.writeChangesResponse(logCtx, w, todoResults, includeAge)
+			return
+		case <-r.Context().Done():
+			log.Ctx(logCtx).Debug().Caller().Msg("client stopped waiting for todo changes")
+			return
+		}
+	}
+}
+
+// Handle HTTP Get for the optional features supported by the store backend
+func (h *Handler) Capabilities(w http.ResponseWriter, r *http.Request) {
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	if err := h.renderJSON(logCtx, w, http.StatusOK, h.store.Capabilities()); err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to marshal json capabilities response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) writeChangesResponse(ctx context.Context, w http.ResponseWriter, todoResults []models.TodoItem,
+	includeAge bool) {
+	if todoResults == nil {
+		todoResults = []models.TodoItem{}
+	}
+	var response interface{} = todoResults
+	if includeAge {
+		withAge := make([]models.TodoItemWithAge, len(todoResults))
+		for i, todoResult := range todoResults {
+			withAge[i] = h.withAge(todoResult)
+		}
+		response = withAge
+	}
+	if err := h.renderTodos(ctx, w, http.StatusOK, response); err != nil {
+		log.Ctx(ctx).Error().Caller().Err(err).Msg("failed to marshal json changes response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// writePoolExhausted responds with 503 and Retry-After when the store's connection pool was exhausted
+func (h *Handler) writePoolExhausted(ctx context.Context, w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, todo.ErrPoolExhausted) {
+		return false
+	}
+
+	log.Ctx(ctx).Warn().Caller().Err(err).Msg("store connection pool exhausted")
+	w.Header().Set("Retry-After", poolExhaustedRetryAfter)
+	h.writeErrorResponse(ctx, w, http.StatusServiceUnavailable, "Service unavailable, try again later")
+	return true
+}
+
+// constraintErrorResponse maps a store constraint violation to a 4xx status and a message safe to return to the client
+func constraintErrorResponse(err error) (int, string, bool) {
+	switch {
+	case err == nil:
+		return 0, "", false
+	case errors.Is(err, todo.ErrDuplicate):
+		return http.StatusConflict, todo.ErrDuplicate.Error(), true
+	case errors.Is(err, todo.ErrForeignKey):
+		return http.StatusConflict, todo.ErrForeignKey.Error(), true
+	case errors.Is(err, todo.ErrNotNull):
+		return http.StatusBadRequest, todo.ErrNotNull.Error(), true
+	case errors.Is(err, todo.ErrCheck):
+		return http.StatusBadRequest, todo.ErrCheck.Error(), true
+	case errors.Is(err, todo.ErrTooLong):
+		return http.StatusBadRequest, todo.ErrTooLong.Error(), true
+	}
+	return 0, "", false
+}
+
+// withAge adds the todo's age in whole seconds by the handler's clock, the same clock used for created_on
+func (h *Handler) withAge(todoResult models.TodoItem) models.TodoItemWithAge {
+	return models.TodoItemWithAge{
+		TodoItem:   todoResult,
+		AgeSeconds: int64(h.now().Sub(todoResult.CreatedOn) / time.Second),
+	}
+}
+
+// parseIncludeAge reads the optional include_age query parameter, it defaults to false
+func parseIncludeAge(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("include_age")
+	if value == "" {
+		return false, nil
+	}
+	includeAge, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New("include_age must be a boolean")
+	}
+	return includeAge, nil
+}
+
 func (h *Handler) writeErrorResponse(ctx context.Context, w http.ResponseWriter, statusCode int, responseMessage string) {
-	if rErr := h.render.JSON(w, statusCode, models.Error{
+	if rErr := h.renderJSON(ctx, w, statusCode, models.Error{
 		Message: responseMessage,
 	}); rErr != nil {
 		log.Ctx(ctx).Error().Caller().Err(rErr).Msg("failed to marshal json response")
@@ -154,7 +558,8 @@ func (h *Handler) writeErrorResponse(ctx context.Context, w http.ResponseWriter,
 	}
 }
 
-func unmarshalRequestBody(req *http.Request, output interface{}) error {
+// unmarshalRequestBody decodes the JSON body into output, bodies with invalid UTF-8 are rejected. In strict mode unknown fields and trailing data are rejected
+func unmarshalRequestBody(req *http.Request, output interface{}, strictMode bool) error {
 	if req.Body == nil {
 		return errors.New("invalid body in request")
 	}
@@ -166,9 +571,21 @@ func unmarshalRequestBody(req *http.Request, output interface{}) error {
 	if err = req.Body.Close(); err != nil {
 		return err
 	}
-	if err = json.Unmarshal(body, &output); err != nil {
+	if !utf8.Valid(body) {
+		return errInvalidUTF8
+	}
+	if !strictMode {
+		return json.Unmarshal(body, &output)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&output); err != nil {
 		return err
 	}
+	if _, err = decoder.Token(); err != io.EOF {
+		return errors.New("unexpected trailing data in body")
+	}
 
 	return nil
 }
@@ -1,34 +1,78 @@
 package todo
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/rs/zerolog"
+	zlog "github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/mock"
 	"github.com/unrolled/render"
 
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/logging"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/requestid"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
 	"github.com/alexsniffin/go-api-starter/mocks"
 )
 
 func initTodoHandler() (Handler, *mocks.TodoStore) {
 	todoStoreMock := mocks.TodoStore{}
 	logger := zerolog.New(os.Stdout)
+	bus, _ := events.NewBus(events.SyncDispatch)
 	todoHandler := Handler{
-		logger: logger,
-		render: render.New(),
-		store:  &todoStoreMock,
+		logger:    logger,
+		render:    render.New(),
+		store:     &todoStoreMock,
+		validator: NoopValidator{},
+		events:    bus,
+		notifier:  newChangeNotifier(),
+		now:       time.Now,
+
+		maxChangesWait: defaultMaxChangesWait,
 	}
+	todoHandler.subscribe()
 	return todoHandler, &todoStoreMock
 }
 
+// stalledWriter fails every body write like a connection whose write deadline expired
+type stalledWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w stalledWriter) Write([]byte) (int, error) {
+	return 0, errors.New("i/o timeout")
+}
+
+// countValidator rejects a todo once the number of accepted todos reaches the max
+type countValidator struct {
+	max   int
+	count int
+}
+
+func (v *countValidator) Validate(_ context.Context, _ models.TodoItem) error {
+	if v.count >= v.max {
+		return errors.New(fmt.Sprint("no more than ", v.max, " todos allowed"))
+	}
+	v.count++
+	return nil
+}
+
 func TestTodoHandler(t *testing.T) {
 	t.Run("foundTodo", func(t *testing.T) {
 		todoHandler, todoStoreMock := initTodoHandler()
@@ -58,7 +102,7 @@ func TestTodoHandler(t *testing.T) {
 			t.FailNow()
 		}
 
-		expected := `{"id":1,"todo":"test","created_on":"0001-01-01T00:00:00Z"}`
+		expected := `{"id":1,"todo":"test","created_on":"0001-01-01T00:00:00Z","updated_on":"0001-01-01T00:00:00Z","attempts":0}`
 		if rr.Body.String() != expected {
 			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
 			t.FailNow()
@@ -133,4 +177,1308 @@ func TestTodoHandler(t *testing.T) {
 			t.Fail()
 		}
 	})
+
+	t.Run("capabilities", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("Capabilities").Return(models.Capabilities{Backend: "postgres", Transactions: true})
+
+		req, err := http.NewRequest("GET", "/capabilities", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Capabilities)
+
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+
+		expected := `{"backend":"postgres","full_text_search":false,"transactions":true,"soft_delete":false,"cursor_pagination":false}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+
+		todoStoreMock.AssertExpectations(t)
+	})
+
+	t.Run("getPoolExhausted", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("GetTodo", mock.Anything, 1).Return(models.TodoItem{}, false, todo.ErrPoolExhausted)
+
+		req, err := http.NewRequest("GET", "/todo/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rCtx := chi.NewRouteContext()
+		rCtx.URLParams.Add("id", "1")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Get).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusServiceUnavailable {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusServiceUnavailable)
+			t.FailNow()
+		}
+		if retryAfter := rr.Header().Get("Retry-After"); retryAfter != "1" {
+			t.Errorf("unexpected Retry-After: got %v want %v", retryAfter, "1")
+		}
+	})
+
+	t.Run("postReturnsItem", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		createdOn := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+		todoStoreMock.On("PostTodo", mock.Anything, mock.MatchedBy(func(item models.TodoItem) bool {
+			return item.Todo == "test"
+		})).Return(models.TodoItem{
+			ID:        1,
+			Todo:      "test",
+			CreatedOn: createdOn,
+		}, nil)
+
+		req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Post)
+
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+
+		expected := `{"id":1,"todo":"test","created_on":"2020-06-01T12:00:00Z","updated_on":"0001-01-01T00:00:00Z","attempts":0}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+			t.FailNow()
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "PostTodo", 1)
+		todoStoreMock.AssertExpectations(t)
+	})
+
+	t.Run("postPublishesCreated", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).Return(models.TodoItem{ID: 1, Todo: "test"}, nil)
+
+		var published []events.Event
+		todoHandler.events.Subscribe(func(event events.Event) {
+			published = append(published, event)
+		})
+
+		req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		http.HandlerFunc(todoHandler.Post).ServeHTTP(httptest.NewRecorder(), req)
+
+		expected := []events.Event{events.TodoCreated{Item: models.TodoItem{ID: 1, Todo: "test"}}}
+		if !reflect.DeepEqual(published, expected) {
+			t.Errorf("unexpected events: got %v want %v", published, expected)
+		}
+	})
+
+	t.Run("deletePublishesDeleted", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("DeleteTodo", mock.Anything, 1).Return(1, nil)
+
+		var published []events.Event
+		todoHandler.events.Subscribe(func(event events.Event) {
+			published = append(published, event)
+		})
+
+		req, err := http.NewRequest("DELETE", "/todo/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rCtx := chi.NewRouteContext()
+		rCtx.URLParams.Add("id", "1")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+		http.HandlerFunc(todoHandler.Delete).ServeHTTP(httptest.NewRecorder(), req)
+
+		expected := []events.Event{events.TodoDeleted{ID: 1}}
+		if !reflect.DeepEqual(published, expected) {
+			t.Errorf("unexpected events: got %v want %v", published, expected)
+		}
+	})
+
+	t.Run("postCreatedOnSource", func(t *testing.T) {
+		dbTime := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+		for _, databaseCreatedOn := range []bool{false, true} {
+			todoHandler, todoStoreMock := initTodoHandler()
+			todoHandler.databaseCreatedOn = databaseCreatedOn
+
+			var sent models.TodoItem
+			todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					sent = args.Get(1).(models.TodoItem)
+				}).
+				Return(func(_ context.Context, item models.TodoItem) models.TodoItem {
+					// the database fills in the column default when created_on is left zero
+					if item.CreatedOn.IsZero() {
+						item.CreatedOn = dbTime
+					}
+					item.ID = 1
+					return item
+				}, nil)
+
+			req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(todoHandler.Post).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+				t.FailNow()
+			}
+			if sent.CreatedOn.IsZero() != databaseCreatedOn {
+				t.Errorf("unexpected created_on sent to store with databaseCreatedOn %v: %v", databaseCreatedOn, sent.CreatedOn)
+			}
+			if strings.Contains(rr.Body.String(), `"created_on":"0001-01-01T00:00:00Z"`) {
+				t.Errorf("expected created_on to be populated with databaseCreatedOn %v: %v", databaseCreatedOn, rr.Body.String())
+			}
+		}
+	})
+
+	t.Run("postUnknownFieldLenient", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).Return(models.TodoItem{ID: 1, Todo: "test"}, nil)
+
+		req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test","unknown":true}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Post)
+
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "PostTodo", 1)
+	})
+
+	t.Run("postUnknownFieldStrict", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoHandler.strictMode = true
+
+		req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test","unknown":true}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Post)
+
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+			t.FailNow()
+		}
+
+		todoStoreMock.AssertNotCalled(t, "PostTodo", mock.Anything, mock.Anything)
+	})
+
+	t.Run("postTrailingDataStrict", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoHandler.strictMode = true
+
+		req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test"} {"todo":"again"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Post)
+
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+			t.FailNow()
+		}
+
+		todoStoreMock.AssertNotCalled(t, "PostTodo", mock.Anything, mock.Anything)
+	})
+
+	t.Run("postInvalidUTF8", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+
+		req, err := http.NewRequest("POST", "/todo/", strings.NewReader("{\"todo\":\"te\xffst\"}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Post)
+
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+			t.FailNow()
+		}
+
+		expected := `{"message":"body must be valid UTF-8"}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+
+		todoStoreMock.AssertNotCalled(t, "PostTodo", mock.Anything, mock.Anything)
+	})
+
+	t.Run("postConcurrentDuplicatesCoalesced", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoHandler.deduper = newWriteDeduper(time.Minute)
+		todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).
+			After(10*time.Millisecond).
+			Return(models.TodoItem{ID: 1, Todo: "test"}, nil)
+
+		var mu sync.Mutex
+		created := 0
+		todoHandler.events.Subscribe(func(event events.Event) {
+			if _, ok := event.(events.TodoCreated); ok {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			}
+		})
+
+		handler := http.HandlerFunc(todoHandler.Post)
+		start := make(chan struct{})
+		bodies := []string{`{"todo":"test"}`, `{"todo":"  test "}`}
+		results := make([]*httptest.ResponseRecorder, len(bodies))
+
+		var wg sync.WaitGroup
+		for i, body := range bodies {
+			wg.Add(1)
+			go func(i int, body string) {
+				defer wg.Done()
+				req, _ := http.NewRequest("POST", "/todo/", strings.NewReader(body))
+				results[i] = httptest.NewRecorder()
+				<-start
+				handler.ServeHTTP(results[i], req)
+			}(i, body)
+		}
+		close(start)
+		wg.Wait()
+
+		expected := `{"id":1,"todo":"test","created_on":"0001-01-01T00:00:00Z","updated_on":"0001-01-01T00:00:00Z","attempts":0}`
+		for _, rr := range results {
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+				t.FailNow()
+			}
+			if rr.Body.String() != expected {
+				t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+			}
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "PostTodo", 1)
+		if created != 1 {
+			t.Errorf("unexpected number of created events: got %v want %v", created, 1)
+		}
+	})
+
+	t.Run("postDuplicatesWithoutDedupe", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).Return(models.TodoItem{ID: 1, Todo: "test"}, nil)
+
+		handler := http.HandlerFunc(todoHandler.Post)
+		for i := 0; i < 2; i++ {
+			req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "PostTodo", 2)
+	})
+
+	t.Run("postDuplicateAfterDelete", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoHandler.deduper = newWriteDeduper(time.Minute)
+		todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).Return(models.TodoItem{ID: 1, Todo: "test"}, nil).Once()
+		todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).Return(models.TodoItem{ID: 2, Todo: "test"}, nil).Once()
+		todoStoreMock.On("DeleteTodo", mock.Anything, 1).Return(1, nil)
+
+		post := func() string {
+			req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(todoHandler.Post).ServeHTTP(rr, req)
+			return rr.Body.String()
+		}
+		post()
+
+		req, err := http.NewRequest("DELETE", "/todo/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rCtx := chi.NewRouteContext()
+		rCtx.URLParams.Add("id", "1")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+		http.HandlerFunc(todoHandler.Delete).ServeHTTP(httptest.NewRecorder(), req)
+
+		// the deleted todo isn't returned for a repeat within the window, it's created again
+		if body := post(); !strings.Contains(body, `"id":2`) {
+			t.Errorf("unexpected body: got %v want the recreated todo", body)
+		}
+		todoStoreMock.AssertNumberOfCalls(t, "PostTodo", 2)
+	})
+
+	t.Run("postConstraintViolation", func(t *testing.T) {
+		cases := []struct {
+			err            error
+			expectedStatus int
+		}{
+			{fmt.Errorf("%w: todo_pkey", todo.ErrDuplicate), http.StatusConflict},
+			{fmt.Errorf("%w: todo_owner_fkey", todo.ErrForeignKey), http.StatusConflict},
+			{fmt.Errorf("%w: todo_todo_not_null", todo.ErrNotNull), http.StatusBadRequest},
+			{fmt.Errorf("%w: todo_todo_check", todo.ErrCheck), http.StatusBadRequest},
+			{todo.ErrTooLong, http.StatusBadRequest},
+			{errors.New("connection refused"), http.StatusInternalServerError},
+		}
+		for _, c := range cases {
+			todoHandler, todoStoreMock := initTodoHandler()
+			todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).Return(models.TodoItem{}, c.err)
+
+			req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(todoHandler.Post).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != c.expectedStatus {
+				t.Errorf("unexpected status code for %v: got %v want %v", c.err, status, c.expectedStatus)
+			}
+		}
+	})
+
+	t.Run("postRejectedByValidator", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoHandler.validator = &countValidator{max: 1}
+		todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).Return(models.TodoItem{ID: 1, Todo: "test"}, nil)
+
+		handler := http.HandlerFunc(todoHandler.Post)
+		statuses := make([]int, 0, 2)
+		for i := 0; i < 2; i++ {
+			req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			statuses = append(statuses, rr.Code)
+
+			if i == 1 {
+				expected := `{"message":"no more than 1 todos allowed"}`
+				if rr.Body.String() != expected {
+					t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+				}
+			}
+		}
+
+		if statuses[0] != http.StatusOK || statuses[1] != http.StatusBadRequest {
+			t.Errorf("unexpected status codes: got %v want %v", statuses, []int{http.StatusOK, http.StatusBadRequest})
+			t.FailNow()
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "PostTodo", 1)
+	})
+
+	t.Run("postInvalidCreatedID", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).Return(models.TodoItem{ID: 0, Todo: "test"}, nil)
+
+		req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Post)
+
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusInternalServerError {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusInternalServerError)
+			t.FailNow()
+		}
+
+		expected := `{"message":"Internal server error with request"}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "PostTodo", 1)
+	})
+
+	t.Run("postValidationFailureMetric", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		before := testutil.ToFloat64(validationFailures.WithLabelValues("post", "todo"))
+
+		req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":""}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Post)
+
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+			t.FailNow()
+		}
+
+		after := testutil.ToFloat64(validationFailures.WithLabelValues("post", "todo"))
+		if after-before != 1 {
+			t.Errorf("unexpected validation failure count: got %v want %v", after-before, 1)
+		}
+
+		todoStoreMock.AssertNotCalled(t, "PostTodo", mock.Anything, mock.Anything)
+	})
+
+	t.Run("changesImmediate", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		since := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+		todoStoreMock.On("GetTodosSince", mock.Anything, since, maxChangesLimit).Return([]models.TodoItem{
+			{ID: 1, Todo: "test", CreatedOn: since.Add(time.Hour)},
+		}, nil)
+
+		req, err := http.NewRequest("GET", "/todo/changes?since=2020-06-01T00:00:00Z&wait=20s", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Changes)
+
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+
+		expected := `[{"id":1,"todo":"test","created_on":"2020-06-01T01:00:00Z","updated_on":"0001-01-01T00:00:00Z","attempts":0}]`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "GetTodosSince", 1)
+	})
+
+	t.Run("changesTimeout", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("GetTodosSince", mock.Anything, mock.Anything, maxChangesLimit).Return(nil, nil)
+
+		req, err := http.NewRequest("GET", "/todo/changes?since=2020-06-01T00:00:00Z&wait=10ms", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Changes)
+
+		start := time.Now()
+		handler.ServeHTTP(rr, req)
+
+		if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+			t.Errorf("returned before wait elapsed: %v", elapsed)
+		}
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+		if rr.Body.String() != `[]` {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), `[]`)
+		}
+	})
+
+	t.Run("changesWaitCappedByRouterTimeout", func(t *testing.T) {
+		for timeout, expected := range map[time.Duration]time.Duration{2 * time.Second: time.Second, 30 * time.Second: 25 * time.Second} {
+			if result := MaxChangesWait(timeout); result != expected {
+				t.Errorf("unexpected max changes wait for %v: got %v want %v", timeout, result, expected)
+			}
+		}
+
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoHandler.maxChangesWait = MaxChangesWait(100 * time.Millisecond)
+		todoStoreMock.On("GetTodosSince", mock.Anything, mock.Anything, maxChangesLimit).Return(nil, nil)
+
+		for wait, expected := range map[string]int{"50ms": http.StatusOK, "200ms": http.StatusBadRequest} {
+			req, err := http.NewRequest("GET", "/todo/changes?since=2020-06-01T00:00:00Z&wait="+wait, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			handler := http.TimeoutHandler(http.HandlerFunc(todoHandler.Changes), 100*time.Millisecond, "timed out")
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != expected {
+				t.Errorf("unexpected status code for %v: got %v want %v", wait, status, expected)
+			}
+		}
+	})
+
+	t.Run("changesWokenByPost", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("GetTodosSince", mock.Anything, mock.Anything, maxChangesLimit).Return(nil, nil).Once()
+		todoStoreMock.On("GetTodosSince", mock.Anything, mock.Anything, maxChangesLimit).Return([]models.TodoItem{
+			{ID: 1, Todo: "test"},
+		}, nil)
+		todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).Return(models.TodoItem{ID: 1, Todo: "test"}, nil)
+
+		req, err := http.NewRequest("GET", "/todo/changes?since=2020-06-01T00:00:00Z&wait=20s", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			http.HandlerFunc(todoHandler.Changes).ServeHTTP(rr, req)
+			close(done)
+		}()
+
+		// post until the waiting request has been woken
+		deadline := time.After(time.Second)
+		for woken := false; !woken; {
+			postReq, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			http.HandlerFunc(todoHandler.Post).ServeHTTP(httptest.NewRecorder(), postReq)
+
+			select {
+			case <-done:
+				woken = true
+			case <-time.After(10 * time.Millisecond):
+			case <-deadline:
+				t.Fatal("changes request wasn't woken by post")
+			}
+		}
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+		}
+		if !strings.Contains(rr.Body.String(), `"id":1`) {
+			t.Errorf("unexpected body: %v", rr.Body.String())
+		}
+	})
+
+	t.Run("changesWokenByTouch", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("GetTodosSince", mock.Anything, mock.Anything, maxChangesLimit).Return(nil, nil).Once()
+		todoStoreMock.On("GetTodosSince", mock.Anything, mock.Anything, maxChangesLimit).Return([]models.TodoItem{
+			{ID: 1, Todo: "test"},
+		}, nil)
+		todoStoreMock.On("TouchTodo", mock.Anything, 1).Return(1, nil)
+
+		req, err := http.NewRequest("GET", "/todo/changes?since=2020-06-01T00:00:00Z&wait=20s", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			http.HandlerFunc(todoHandler.Changes).ServeHTTP(rr, req)
+			close(done)
+		}()
+
+		// touch until the waiting request has been woken
+		deadline := time.After(time.Second)
+		for woken := false; !woken; {
+			touchReq, err := http.NewRequest("POST", "/todo/1/touch", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rCtx := chi.NewRouteContext()
+			rCtx.URLParams.Add("id", "1")
+			touchReq = touchReq.WithContext(context.WithValue(touchReq.Context(), chi.RouteCtxKey, rCtx))
+			http.HandlerFunc(todoHandler.Touch).ServeHTTP(httptest.NewRecorder(), touchReq)
+
+			select {
+			case <-done:
+				woken = true
+			case <-time.After(10 * time.Millisecond):
+			case <-deadline:
+				t.Fatal("changes request wasn't woken by touch")
+			}
+		}
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+		}
+		if !strings.Contains(rr.Body.String(), `"id":1`) {
+			t.Errorf("unexpected body: %v", rr.Body.String())
+		}
+	})
+
+	t.Run("changesBadSince", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+
+		req, err := http.NewRequest("GET", "/todo/changes?since=yesterday", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Changes)
+
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+		}
+
+		todoStoreMock.AssertNotCalled(t, "GetTodosSince", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("upstreamRequestIDInStoreLogs", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		var buf bytes.Buffer
+		id := 1
+		todoStoreMock.On("GetTodo", mock.Anything, id).Run(func(args mock.Arguments) {
+			zlog.Ctx(args.Get(0).(context.Context)).Info().Msg("store call")
+		}).Return(models.TodoItem{ID: id, Todo: "test"}, true, nil)
+
+		req, err := http.NewRequest("GET", fmt.Sprintf("/todo/%d", id), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rCtx := chi.NewRouteContext()
+		rCtx.URLParams.Add("id", strconv.Itoa(id))
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+		rr := httptest.NewRecorder()
+		handler := requestid.NewHandlerFunc(nil, "")(logging.NewHandlerFunc(zerolog.New(&buf))(http.HandlerFunc(todoHandler.Get)))
+
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+
+		reqID := rr.Header().Get("Request-Id")
+		var storeLog string
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if strings.Contains(line, "store call") {
+				storeLog = line
+			}
+		}
+		if reqID == "" || !strings.Contains(storeLog, fmt.Sprintf(`"req_id":"%s"`, reqID)) {
+			t.Errorf("expected request id %v in store log: %v", reqID, storeLog)
+		}
+		if !strings.Contains(storeLog, `"id":1`) {
+			t.Errorf("expected todo id in store log: %v", storeLog)
+		}
+	})
+
+	t.Run("shareTodo", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoHandler.shareSecret = "secret"
+		id := 1
+		todoStoreMock.On("GetTodo", mock.Anything, id).Return(models.TodoItem{ID: id, Todo: "test"}, true, nil)
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("/todo/%d/share", id), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rCtx := chi.NewRouteContext()
+		rCtx.URLParams.Add("id", strconv.Itoa(id))
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Share)
+
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusCreated {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusCreated)
+			t.FailNow()
+		}
+
+		var share models.ShareResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &share); err != nil {
+			t.Fatal(err)
+		}
+		if share.URL != "/shared/"+share.Token {
+			t.Errorf("unexpected share url: %v", share.URL)
+		}
+		if until := time.Until(share.ExpiresOn); until <= defaultShareTTL-time.Minute || until > defaultShareTTL {
+			t.Errorf("unexpected share expiry: %v", share.ExpiresOn)
+		}
+
+		// the minted token resolves to the todo
+		req, err = http.NewRequest("GET", share.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rCtx = chi.NewRouteContext()
+		rCtx.URLParams.Add("token", share.Token)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+		rr = httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Shared).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+
+		expected := `{"id":1,"todo":"test","created_on":"0001-01-01T00:00:00Z","updated_on":"0001-01-01T00:00:00Z","attempts":0}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+	})
+
+	t.Run("shareMissingTodo", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoHandler.shareSecret = "secret"
+		id := 1
+		todoStoreMock.On("GetTodo", mock.Anything, id).Return(models.TodoItem{}, false, nil)
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("/todo/%d/share", id), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rCtx := chi.NewRouteContext()
+		rCtx.URLParams.Add("id", strconv.Itoa(id))
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Share).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusNotFound {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusNotFound)
+		}
+	})
+
+	t.Run("sharedRejectedTokens", func(t *testing.T) {
+		cases := map[string]string{
+			"expired":         signShareToken("secret", 1, 0, time.Now().Add(-time.Second)),
+			"wrongSecret":     signShareToken("other", 1, 0, time.Now().Add(time.Hour)),
+			"tamperedID":      strings.Replace(signShareToken("secret", 1, 0, time.Now().Add(time.Hour)), "1.", "2.", 1),
+			"tamperedVersion": strings.Replace(signShareToken("secret", 1, 0, time.Now().Add(time.Hour)), ".0.", ".1.", 1),
+			"malformed":       "not-a-token",
+			"missingParts":    "1.0.2",
+		}
+		for name, token := range cases {
+			todoHandler, todoStoreMock := initTodoHandler()
+			todoHandler.shareSecret = "secret"
+
+			req, err := http.NewRequest("GET", "/shared/"+token, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rCtx := chi.NewRouteContext()
+			rCtx.URLParams.Add("token", token)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(todoHandler.Shared).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Errorf("unexpected status code for %v: got %v want %v", name, status, http.StatusNotFound)
+			}
+			todoStoreMock.AssertNotCalled(t, "GetTodo", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("revokeShares", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			count  int
+			status int
+		}{
+			{"revoked", 1, http.StatusNoContent},
+			{"missing", 0, http.StatusNotFound},
+		}
+		for _, c := range cases {
+			todoHandler, todoStoreMock := initTodoHandler()
+			todoHandler.shareSecret = "secret"
+			id := 1
+			todoStoreMock.On("RevokeShares", mock.Anything, id).Return(c.count, nil)
+
+			req, err := http.NewRequest("DELETE", fmt.Sprintf("/todo/%d/share", id), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rCtx := chi.NewRouteContext()
+			rCtx.URLParams.Add("id", strconv.Itoa(id))
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(todoHandler.RevokeShares).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != c.status {
+				t.Errorf("unexpected status code for %v: got %v want %v", c.name, status, c.status)
+			}
+		}
+	})
+
+	t.Run("sharedRevokedToken", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoHandler.shareSecret = "secret"
+		// the links of version 0 were revoked, bumping the todo to version 1
+		todoStoreMock.On("GetTodo", mock.Anything, 1).Return(models.TodoItem{ID: 1, Todo: "test", ShareVersion: 1}, true, nil)
+
+		cases := []struct {
+			name    string
+			version int
+			status  int
+		}{
+			{"revoked", 0, http.StatusNotFound},
+			{"current", 1, http.StatusOK},
+		}
+		for _, c := range cases {
+			token := signShareToken("secret", 1, c.version, time.Now().Add(time.Hour))
+			req, err := http.NewRequest("GET", "/shared/"+token, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rCtx := chi.NewRouteContext()
+			rCtx.URLParams.Add("token", token)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(todoHandler.Shared).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != c.status {
+				t.Errorf("unexpected status code for %v: got %v want %v", c.name, status, c.status)
+			}
+		}
+	})
+
+	t.Run("postIfNoneMatch", func(t *testing.T) {
+		cases := []struct {
+			name     string
+			created  bool
+			status   int
+			expected string
+		}{
+			{"created", true, http.StatusOK, `{"id":1,"todo":"test","created_on":"0001-01-01T00:00:00Z","updated_on":"0001-01-01T00:00:00Z","attempts":0}`},
+			{"exists", false, http.StatusPreconditionFailed, `{"message":"A todo with the same text already exists"}`},
+		}
+		for _, c := range cases {
+			todoHandler, todoStoreMock := initTodoHandler()
+			result := models.TodoItem{}
+			if c.created {
+				result = models.TodoItem{ID: 1, Todo: "test"}
+			}
+			todoStoreMock.On("PostTodoIfNotExists", mock.Anything, mock.Anything).Return(result, c.created, nil)
+
+			req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("If-None-Match", "*")
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(todoHandler.Post).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != c.status {
+				t.Errorf("unexpected status code for %v: got %v want %v", c.name, status, c.status)
+				t.FailNow()
+			}
+			if rr.Body.String() != c.expected {
+				t.Errorf("unexpected body for %v: got %v want %v", c.name, rr.Body.String(), c.expected)
+			}
+			todoStoreMock.AssertNotCalled(t, "PostTodo", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("postMalformedIfNoneMatch", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).Return(models.TodoItem{ID: 1, Todo: "test"}, nil)
+
+		req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("If-None-Match", "*foo")
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Post).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+		}
+		todoStoreMock.AssertNotCalled(t, "PostTodoIfNotExists", mock.Anything, mock.Anything)
+	})
+
+	t.Run("includeAge", func(t *testing.T) {
+		createdOn := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+		cases := []struct {
+			name     string
+			query    string
+			expected string
+		}{
+			{"default", "", `{"id":1,"todo":"test","created_on":"2020-06-01T12:00:00Z","updated_on":"0001-01-01T00:00:00Z","attempts":0}`},
+			{"included", "?include_age=true", `{"id":1,"todo":"test","created_on":"2020-06-01T12:00:00Z","updated_on":"0001-01-01T00:00:00Z","attempts":0,"age_seconds":90}`},
+		}
+		for _, c := range cases {
+			todoHandler, todoStoreMock := initTodoHandler()
+			todoHandler.now = func() time.Time { return createdOn.Add(90 * time.Second) }
+			id := 1
+			todoStoreMock.On("GetTodo", mock.Anything, id).Return(models.TodoItem{ID: id, Todo: "test", CreatedOn: createdOn}, true, nil)
+
+			req, err := http.NewRequest("GET", fmt.Sprintf("/todo/%d%s", id, c.query), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rCtx := chi.NewRouteContext()
+			rCtx.URLParams.Add("id", strconv.Itoa(id))
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(todoHandler.Get).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("unexpected status code for %v: got %v want %v", c.name, status, http.StatusOK)
+				t.FailNow()
+			}
+			if rr.Body.String() != c.expected {
+				t.Errorf("unexpected body for %v: got %v want %v", c.name, rr.Body.String(), c.expected)
+			}
+		}
+	})
+
+	t.Run("changesIncludeAge", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		createdOn := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+		todoHandler.now = func() time.Time { return createdOn.Add(time.Hour) }
+		todoStoreMock.On("GetTodosSince", mock.Anything, mock.Anything, maxChangesLimit).
+			Return([]models.TodoItem{{ID: 1, Todo: "test", CreatedOn: createdOn}}, nil)
+
+		req, err := http.NewRequest("GET", "/todo/changes?since=2020-06-01T00:00:00Z&include_age=true", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Changes).ServeHTTP(rr, req)
+
+		expected := `[{"id":1,"todo":"test","created_on":"2020-06-01T12:00:00Z","updated_on":"0001-01-01T00:00:00Z","attempts":0,"age_seconds":3600}]`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+	})
+
+	t.Run("includeAgeInvalid", func(t *testing.T) {
+		todoHandler, _ := initTodoHandler()
+
+		req, err := http.NewRequest("GET", "/todo/1?include_age=maybe", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rCtx := chi.NewRouteContext()
+		rCtx.URLParams.Add("id", "1")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Get).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("touch", func(t *testing.T) {
+		cases := []struct {
+			name      string
+			count     int
+			status    int
+			published []events.Event
+		}{
+			{"touched", 1, http.StatusOK, []events.Event{events.TodoUpdated{ID: 1}}},
+			{"missing", 0, http.StatusNotFound, nil},
+		}
+		for _, c := range cases {
+			todoHandler, todoStoreMock := initTodoHandler()
+			id := 1
+			todoStoreMock.On("TouchTodo", mock.Anything, id).Return(c.count, nil)
+
+			var published []events.Event
+			todoHandler.events.Subscribe(func(event events.Event) {
+				published = append(published, event)
+			})
+
+			req, err := http.NewRequest("POST", fmt.Sprintf("/todo/%d/touch", id), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rCtx := chi.NewRouteContext()
+			rCtx.URLParams.Add("id", strconv.Itoa(id))
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(todoHandler.Touch).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != c.status {
+				t.Errorf("unexpected status code for %v: got %v want %v", c.name, status, c.status)
+			}
+			if !reflect.DeepEqual(published, c.published) {
+				t.Errorf("unexpected events for %v: got %v want %v", c.name, published, c.published)
+			}
+		}
+	})
+
+	t.Run("neighbors", func(t *testing.T) {
+		prevID, nextID := 1, 3
+		cases := []struct {
+			name     string
+			query    string
+			sort     string
+			result   models.TodoWithNeighbors
+			err      error
+			status   int
+			expected string
+		}{
+			{"middle", "", "created_on", models.TodoWithNeighbors{TodoItem: models.TodoItem{ID: 2, Todo: "test"}, PrevID: &prevID, NextID: &nextID}, nil, http.StatusOK,
+				`{"id":2,"todo":"test","created_on":"0001-01-01T00:00:00Z","updated_on":"0001-01-01T00:00:00Z","attempts":0,"prev_id":1,"next_id":3}`},
+			{"first", "?sort=id", "id", models.TodoWithNeighbors{TodoItem: models.TodoItem{ID: 2, Todo: "test"}, NextID: &nextID}, nil, http.StatusOK,
+				`{"id":2,"todo":"test","created_on":"0001-01-01T00:00:00Z","updated_on":"0001-01-01T00:00:00Z","attempts":0,"prev_id":null,"next_id":3}`},
+			{"invalidSort", "?sort=todo", "todo", models.TodoWithNeighbors{}, todo.ErrInvalidSort, http.StatusBadRequest,
+				`{"message":"sort must be one of id or created_on"}`},
+		}
+		for _, c := range cases {
+			todoHandler, todoStoreMock := initTodoHandler()
+			todoStoreMock.On("GetTodoWithNeighbors", mock.Anything, 2, c.sort).Return(c.result, c.err == nil, c.err)
+
+			req, err := http.NewRequest("GET", "/todo/2/neighbors"+c.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rCtx := chi.NewRouteContext()
+			rCtx.URLParams.Add("id", "2")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(todoHandler.Neighbors).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != c.status {
+				t.Errorf("unexpected status code for %v: got %v want %v", c.name, status, c.status)
+				t.FailNow()
+			}
+			if rr.Body.String() != c.expected {
+				t.Errorf("unexpected body for %v: got %v want %v", c.name, rr.Body.String(), c.expected)
+			}
+		}
+	})
+
+	t.Run("changesBadWait", func(t *testing.T) {
+		cases := map[string]string{
+			"-1s":      `{"message":"wait must be at least 0s"}`,
+			"26s":      `{"message":"wait must be at most 25s"}`,
+			"1000000h": `{"message":"wait must be at most 25s"}`,
+			"soon":     `{"message":"wait must be a duration like 10s"}`,
+		}
+		for wait, expected := range cases {
+			todoHandler, _ := initTodoHandler()
+
+			req, err := http.NewRequest("GET", "/todo/changes?since=2020-06-01T00:00:00Z&wait="+wait, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(todoHandler.Changes).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("unexpected status code for %v: got %v want %v", wait, status, http.StatusBadRequest)
+			}
+			if rr.Body.String() != expected {
+				t.Errorf("unexpected body for %v: got %v want %v", wait, rr.Body.String(), expected)
+			}
+		}
+	})
+
+	t.Run("zeroTimestamps", func(t *testing.T) {
+		createdOn := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+		cases := []struct {
+			mode     string
+			expected string
+		}{
+			{ZeroTimestampsKeep, `{"id":1,"todo":"test","created_on":"2020-06-01T00:00:00Z","updated_on":"0001-01-01T00:00:00Z","attempts":0}`},
+			{ZeroTimestampsNull, `{"attempts":0,"created_on":"2020-06-01T00:00:00Z","id":1,"todo":"test","updated_on":null}`},
+			{ZeroTimestampsOmit, `{"attempts":0,"created_on":"2020-06-01T00:00:00Z","id":1,"todo":"test"}`},
+		}
+		for _, c := range cases {
+			todoHandler, todoStoreMock := initTodoHandler()
+			todoHandler.zeroTimestamps = c.mode
+			todoStoreMock.On("GetTodo", mock.Anything, 1).Return(models.TodoItem{ID: 1, Todo: "test", CreatedOn: createdOn}, true, nil)
+
+			var buf bytes.Buffer
+			req, err := http.NewRequest("GET", "/todo/1", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rCtx := chi.NewRouteContext()
+			rCtx.URLParams.Add("id", "1")
+			logger := zerolog.New(&buf)
+			ctx := logger.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+			req = req.WithContext(ctx)
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(todoHandler.Get).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("unexpected status code for %v: got %v want %v", c.mode, status, http.StatusOK)
+				t.FailNow()
+			}
+			if rr.Body.String() != c.expected {
+				t.Errorf("unexpected body for %v: got %v want %v", c.mode, rr.Body.String(), c.expected)
+			}
+			if !strings.Contains(buf.String(), "todo response has a zero timestamp") {
+				t.Errorf("missing zero timestamp warning for %v: got %v", c.mode, buf.String())
+			}
+		}
+	})
+
+	t.Run("postCreated", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoHandler.postCreated = true
+		todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).Return(models.TodoItem{ID: 7, Todo: "test"}, nil)
+
+		req, err := http.NewRequest("POST", "/todo/", strings.NewReader(`{"todo":"test"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Post).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusCreated {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusCreated)
+			t.FailNow()
+		}
+		if location := rr.Header().Get("Location"); location != "/api/todo/7" {
+			t.Errorf("unexpected Location: got %v want %v", location, "/api/todo/7")
+		}
+		expected := `{"id":7,"todo":"test","created_on":"0001-01-01T00:00:00Z","updated_on":"0001-01-01T00:00:00Z","attempts":0}`
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+	})
+
+	t.Run("stalledClientWrite", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("GetTodosSince", mock.Anything, mock.Anything, mock.Anything).Return([]models.TodoItem{
+			{ID: 1, Todo: "test", CreatedOn: time.Date(2020, 6, 1, 0, 0, 1, 0, time.UTC), UpdatedOn: time.Date(2020, 6, 1, 0, 0, 1, 0, time.UTC)},
+		}, nil)
+
+		var buf bytes.Buffer
+		req, err := http.NewRequest("GET", "/todo/changes?since=2020-06-01T00:00:00Z", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger := zerolog.New(&buf)
+		req = req.WithContext(logger.WithContext(req.Context()))
+
+		rr := stalledWriter{httptest.NewRecorder()}
+		done := make(chan struct{})
+		go func() {
+			http.HandlerFunc(todoHandler.Changes).ServeHTTP(rr, req)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("handler didn't return after the write failed")
+		}
+		if rr.Code != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if !strings.Contains(buf.String(), "failed to write response, the client stalled or disconnected") {
+			t.Errorf("missing write failure log: got %v", buf.String())
+		}
+	})
+
+	t.Run("attempts", func(t *testing.T) {
+		cases := []struct {
+			name      string
+			attempts  int
+			err       error
+			status    int
+			body      string
+			published []events.Event
+		}{
+			{"incremented", 2, nil, http.StatusOK, `{"attempts":2}`, []events.Event{events.TodoUpdated{ID: 1}}},
+			{"missing", 0, todo.ErrNotFound, http.StatusNotFound, `{"message":"Todo not found"}`, nil},
+		}
+		for _, c := range cases {
+			todoHandler, todoStoreMock := initTodoHandler()
+			id := 1
+			todoStoreMock.On("IncrementAttempts", mock.Anything, id).Return(c.attempts, c.err)
+
+			var published []events.Event
+			todoHandler.events.Subscribe(func(event events.Event) {
+				published = append(published, event)
+			})
+
+			req, err := http.NewRequest("POST", fmt.Sprintf("/todo/%d/attempts", id), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rCtx := chi.NewRouteContext()
+			rCtx.URLParams.Add("id", strconv.Itoa(id))
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(todoHandler.Attempts).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != c.status {
+				t.Errorf("unexpected status code for %v: got %v want %v", c.name, status, c.status)
+			}
+			if rr.Body.String() != c.body {
+				t.Errorf("unexpected body for %v: got %v want %v", c.name, rr.Body.String(), c.body)
+			}
+			if !reflect.DeepEqual(published, c.published) {
+				t.Errorf("unexpected events for %v: got %v want %v", c.name, published, c.published)
+			}
+		}
+	})
 }
@@ -0,0 +1,21 @@
+package todo
+
+import (
+	"context"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// TodoValidator enforces custom business rules on a TodoItem before it's persisted, e.g. cross-field or DB-dependent
+// rules which can't be expressed as field validation
+type TodoValidator interface {
+	Validate(ctx context.Context, item models.TodoItem) error
+}
+
+// NoopValidator is the default TodoValidator, it accepts every TodoItem
+type NoopValidator struct{}
+
+// Validate always returns nil
+func (NoopValidator) Validate(context.Context, models.TodoItem) error {
+	return nil
+}
@@ -0,0 +1,36 @@
+package todo
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// writeErrorRecorder keeps the first error writing the response body. A client which stalls reading the response
+// fails the write once the server's WriteTimeout expires, a disconnected one fails it right away.
+type writeErrorRecorder struct {
+	http.ResponseWriter
+	err error
+}
+
+func (w *writeErrorRecorder) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// renderJSON renders v and only returns marshal errors. render drops write errors, so they're recorded here and
+// logged instead of retrying with an error response the client can't receive, net/http closes the connection once the
+// handler returns.
+func (h *Handler) renderJSON(ctx context.Context, w http.ResponseWriter, statusCode int, v interface{}) error {
+	recorder := &writeErrorRecorder{ResponseWriter: w}
+	err := h.render.JSON(recorder, statusCode, v)
+	if recorder.err != nil {
+		log.Ctx(ctx).Warn().Caller().Err(recorder.err).Msg("failed to write response, the client stalled or disconnected")
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,23 @@
+package models
+
+// ReadOnlyRequest toggles read-only mode, it's also the response with the new state
+type ReadOnlyRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// IndexAdvice lists the columns filtered or sorted on by the store which no index of their table starts with
+type IndexAdvice struct {
+	// Degraded is true when pg_stat_statements isn't available, the advice then covers every column the store's
+	// queries use rather than only the ones actually being queried
+	Degraded bool           `json:"degraded"`
+	Missing  []ColumnAdvice `json:"missing"`
+}
+
+// ColumnAdvice is a column which would benefit from an index
+type ColumnAdvice struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	// Calls is the number of recorded statement executions using the column, it's omitted when degraded
+	Calls      *int64 `json:"calls,omitempty"`
+	Suggestion string `json:"suggestion"`
+}
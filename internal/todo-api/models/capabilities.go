@@ -0,0 +1,10 @@
+package models
+
+// Capabilities lists the optional features supported by the active store backend
+type Capabilities struct {
+	Backend          string `json:"backend"`
+	FullTextSearch   bool   `json:"full_text_search"`
+	Transactions     bool   `json:"transactions"`
+	SoftDelete       bool   `json:"soft_delete"`
+	CursorPagination bool   `json:"cursor_pagination"`
+}
@@ -5,22 +5,51 @@ import (
 )
 
 type Config struct {
-	Environment string
-	Logger      models.Logger
-	HTTPServer  HTTPServerConfig
-	HTTPRouter  HTTPRouterConfig
-	Database    DatabaseConfig
+	Environment     string
+	ShutdownSignals []string
+	Logger          models.Logger
+	Renderer        models.Renderer
+	HTTPServer      HTTPServerConfig
+	HTTPRouter      HTTPRouterConfig
+	Database        DatabaseConfig
+	Events          EventsConfig
 }
 
 type HTTPServerConfig struct {
-	Port int
+	Port                 int
+	TLSCertFile          string
+	TLSKeyFile           string
+	TLSMinVersion        string
+	MinHTTPVersion       string
+	ReadHeaderTimeoutSec int
+	WriteTimeoutSec      int
+	MaxHeaderBytes       int
 }
 
 type HTTPRouterConfig struct {
-	TimeoutSec     int
-	AllowedOrigins []string
-	AllowedMethods []string
-	AllowedHeaders []string
+	TimeoutSec               int
+	AllowedOrigins           []string
+	AllowedMethods           []string
+	AllowedHeaders           []string
+	StrictMode               bool
+	ReadOnly                 bool
+	DedupeWindowSec          int
+	RequiredHeaders          []string
+	SigningSecret            string `redact:"true"`
+	SigningMaxSkewSec        int
+	AdminToken               string `redact:"true"`
+	MaxDecompressedBodyBytes int64
+	NoStorePaths             []string
+	CompressionLevel         int
+	RequestIDHeaders         []string
+	RequestIDResponseHeader  string
+	ShedInFlightThreshold    int
+	ShareSecret              string `redact:"true"`
+	ShareTTLSec              int
+	ReadinessCacheTTLSec     int
+	ZeroTimestamps           string
+	LogBodyBytes             int
+	PostCreated              bool
 }
 
 type DatabaseConfig struct {
@@ -28,7 +57,20 @@ type DatabaseConfig struct {
 	Port        int
 	User        string
 	DbName      string
-	Password    string
+	Password    string `redact:"true"`
 	Tables      []string
 	CreateTable bool
+
+	OnInitFailure          string
+	ReconnectIntervalSec   int
+	ConnectRetryMaxWaitSec int
+	ConnectRetryBackoffSec int
+	PoolSize               int
+	PoolTimeoutSec         int
+	DatabaseCreatedOn      bool
+	StartupSelfTest        bool
+}
+
+type EventsConfig struct {
+	Dispatch string
 }
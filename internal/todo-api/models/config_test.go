@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/alexsniffin/go-api-starter/pkg/config"
+)
+
+func TestConfigRedact(t *testing.T) {
+	t.Run("masksSecrets", func(t *testing.T) {
+		cfg := Config{
+			HTTPRouter: HTTPRouterConfig{
+				SigningSecret: "signing-secret",
+				AdminToken:    "admin-token",
+				ShareSecret:   "share-secret",
+			},
+			Database: DatabaseConfig{
+				User:     "test",
+				Password: "pass123",
+			},
+		}
+
+		redacted := config.Redact(&cfg).(Config)
+		secrets := map[string]string{
+			"Database.Password":        redacted.Database.Password,
+			"HTTPRouter.SigningSecret": redacted.HTTPRouter.SigningSecret,
+			"HTTPRouter.AdminToken":    redacted.HTTPRouter.AdminToken,
+			"HTTPRouter.ShareSecret":   redacted.HTTPRouter.ShareSecret,
+		}
+		for field, value := range secrets {
+			if value != "*****" {
+				t.Errorf("unexpected %v: got %v want %v", field, value, "*****")
+			}
+		}
+		if redacted.Database.User != "test" {
+			t.Errorf("unexpected Database.User: got %v want %v", redacted.Database.User, "test")
+		}
+	})
+}
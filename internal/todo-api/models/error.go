@@ -1,5 +1,6 @@
 package models
 
 type Error struct {
+	Code    string `json:"code,omitempty"`
 	Message string `json:"message"`
 }
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// ShareResponse is the link minted for sharing a single todo
+type ShareResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresOn time.Time `json:"expires_on"`
+}
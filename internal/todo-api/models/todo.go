@@ -6,17 +6,37 @@ import (
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
 
-// TodoItem model
+// TodoMaxLength is the maximum number of characters in a todo, it's also the size of the todo column
+const TodoMaxLength = 1000
+
+// TodoItem model, ShareVersion is signed into share links so bumping it revokes the links minted before
 type TodoItem struct {
-	tableName struct{}  `pg:"todo"` // nolint:structcheck,unused
-	ID        int       `json:"id" pg:"id,pk"`
-	Todo      string    `json:"todo" pg:"todo"`
-	CreatedOn time.Time `json:"created_on" pg:"created_on"`
+	tableName    struct{}  `pg:"todo"` // nolint:structcheck,unused
+	ID           int       `json:"id" pg:"id,pk"`
+	Todo         string    `json:"todo" pg:"todo" sql:",type:varchar(1000)"`
+	CreatedOn    time.Time `json:"created_on" pg:"created_on" sql:",default:now()"`
+	UpdatedOn    time.Time `json:"updated_on" pg:"updated_on" sql:",default:now()"`
+	Attempts     int       `json:"attempts" pg:"attempts" sql:",notnull,default:0"`
+	ShareVersion int       `json:"-" pg:"share_version" sql:",notnull,default:0"`
+}
+
+// TodoItemWithAge is a TodoItem with its age computed when it's read
+type TodoItemWithAge struct {
+	TodoItem
+	AgeSeconds int64 `json:"age_seconds"`
+}
+
+// TodoWithNeighbors is a TodoItem with the ids of the todos before and after it in a sort order, they're nil at the
+// ends
+type TodoWithNeighbors struct {
+	TodoItem
+	PrevID *int `json:"prev_id" pg:"prev_id"`
+	NextID *int `json:"next_id" pg:"next_id"`
 }
 
-// TodoPostResponse response model to POST
-type TodoPostResponse struct {
-	ID int `json:"id"`
+// TodoAttemptsResponse response model to POST attempts
+type TodoAttemptsResponse struct {
+	Attempts int `json:"attempts"`
 }
 
 // TodoPostRequest request model to POST
@@ -26,6 +46,6 @@ type TodoPostRequest struct {
 
 func (tReq *TodoPostRequest) IsValid() error {
 	return validation.ValidateStruct(tReq,
-		validation.Field(&tReq.Todo, validation.Required),
+		validation.Field(&tReq.Todo, validation.Required, validation.RuneLength(0, TodoMaxLength)),
 	)
 }
@@ -0,0 +1,11 @@
+//go:build go1.20
+// +build go1.20
+
+package http
+
+import "net/http"
+
+// passGeneralOptions hands `OPTIONS *` requests to the router instead of the default net/http response
+func passGeneralOptions(s *http.Server) {
+	s.DisableGeneralOptionsHandler = true
+}
@@ -0,0 +1,23 @@
+//go:build go1.20
+// +build go1.20
+
+package http
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+func TestNewServer_PassesGeneralOptions(t *testing.T) {
+	server, err := NewServer(models.HTTPServerConfig{Port: 8080}, zerolog.New(os.Stdout), okHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !server.DisableGeneralOptionsHandler {
+		t.Error("expected OPTIONS * to be passed to the handler")
+	}
+}
@@ -1,14 +1,31 @@
 package http
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
 )
 
+const (
+	// defaultReadHeaderTimeout bounds how long a client may take to send the request headers when none is configured
+	defaultReadHeaderTimeout = 5 * time.Second
+	// defaultMaxHeaderBytes bounds the size of the request headers when none is configured
+	defaultMaxHeaderBytes = 64 << 10
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
 type Server struct {
 	*http.Server
 
@@ -16,22 +33,61 @@ type Server struct {
 	logger zerolog.Logger
 }
 
-func NewServer(cfg models.HTTPServerConfig, logger zerolog.Logger, routerHandler http.Handler) *Server {
+func NewServer(cfg models.HTTPServerConfig, logger zerolog.Logger, routerHandler http.Handler) (*Server, error) {
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	handler, err := newProtocolHandler(cfg, routerHandler)
+	if err != nil {
+		return nil, err
+	}
+
+	readHeaderTimeout, err := newReadHeaderTimeout(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.WriteTimeoutSec < 0 {
+		return nil, errors.New(fmt.Sprintf("invalid WriteTimeoutSec: %d", cfg.WriteTimeoutSec))
+	}
+
+	maxHeaderBytes := cfg.MaxHeaderBytes
+	if maxHeaderBytes < 0 {
+		return nil, errors.New(fmt.Sprintf("invalid MaxHeaderBytes: %d", maxHeaderBytes))
+	}
+	if maxHeaderBytes == 0 {
+		maxHeaderBytes = defaultMaxHeaderBytes
+	}
+
+	httpServer := &http.Server{
+		Addr:              fmt.Sprint(":", cfg.Port),
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: readHeaderTimeout,
+		WriteTimeout:      time.Duration(cfg.WriteTimeoutSec) * time.Second,
+		MaxHeaderBytes:    maxHeaderBytes,
+	}
+	passGeneralOptions(httpServer)
+
 	return &Server{
-		&http.Server{
-			Addr:    fmt.Sprint(":", cfg.Port),
-			Handler: routerHandler,
-		},
+		httpServer,
 		cfg,
 		logger,
-	}
+	}, nil
 }
 
 // Start an HTTP server which will block the current goroutine. Will write an error to the `errCh` if a problem occurs.
 func (h *Server) Start(errCh chan<- error) {
 	h.logger.Info().Msg(fmt.Sprint("running server on 0.0.0.0:", h.cfg.Port))
 
-	err := h.ListenAndServe()
+	var err error
+	if h.TLSConfig != nil {
+		err = h.ListenAndServeTLS(h.cfg.TLSCertFile, h.cfg.TLSKeyFile)
+	} else {
+		err = h.ListenAndServe()
+	}
 	if err != http.ErrServerClosed {
 		h.logger.Error().Caller().Err(err).Msg("http server stopped unexpected")
 		errCh <- err
@@ -39,3 +95,61 @@ func (h *Server) Start(errCh chan<- error) {
 		h.logger.Info().Msg("http server process stopped")
 	}
 }
+
+// newReadHeaderTimeout returns the timeout for reading request headers. It's kept separate from the body read so slow
+// clients trickling headers byte by byte (slowloris) can't hold connections open, while large bodies aren't cut off.
+func newReadHeaderTimeout(cfg models.HTTPServerConfig) (time.Duration, error) {
+	if cfg.ReadHeaderTimeoutSec < 0 {
+		return 0, errors.New(fmt.Sprintf("invalid ReadHeaderTimeoutSec: %d", cfg.ReadHeaderTimeoutSec))
+	}
+	if cfg.ReadHeaderTimeoutSec == 0 {
+		return defaultReadHeaderTimeout, nil
+	}
+	return time.Duration(cfg.ReadHeaderTimeoutSec) * time.Second, nil
+}
+
+// newTLSConfig returns the TLS config enforcing the minimum TLS version, or nil when TLS isn't configured
+func newTLSConfig(cfg models.HTTPServerConfig) (*tls.Config, error) {
+	if cfg.TLSCertFile == "" && cfg.TLSKeyFile == "" {
+		return nil, nil
+	}
+	if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+		return nil, errors.New("both TLSCertFile and TLSKeyFile are required to enable tls")
+	}
+
+	minVersion := uint16(tls.VersionTLS12)
+	if cfg.TLSMinVersion != "" {
+		version, ok := tlsVersions[cfg.TLSMinVersion]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("unsupported TLSMinVersion: %s", cfg.TLSMinVersion))
+		}
+		minVersion = version
+	}
+
+	return &tls.Config{
+		MinVersion: minVersion,
+	}, nil
+}
+
+// newProtocolHandler wraps the handler to reject requests below the minimum HTTP version with 505
+func newProtocolHandler(cfg models.HTTPServerConfig, next http.Handler) (http.Handler, error) {
+	if cfg.MinHTTPVersion == "" {
+		return next, nil
+	}
+
+	major, minor, ok := http.ParseHTTPVersion("HTTP/" + cfg.MinHTTPVersion)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("unsupported MinHTTPVersion: %s", cfg.MinHTTPVersion))
+	}
+	if major >= 2 && cfg.TLSCertFile == "" {
+		return nil, errors.New("MinHTTPVersion 2.0 requires tls to be enabled")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.ProtoAtLeast(major, minor) {
+			w.WriteHeader(http.StatusHTTPVersionNotSupported)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}), nil
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestNewServer(t *testing.T) {
+	t.Run("defaultWithoutTLS", func(t *testing.T) {
+		server, err := NewServer(models.HTTPServerConfig{Port: 8080}, zerolog.New(os.Stdout), okHandler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if server.TLSConfig != nil {
+			t.Errorf("unexpected tls config: %v", server.TLSConfig)
+		}
+	})
+
+	t.Run("readHeaderTimeout", func(t *testing.T) {
+		server, err := NewServer(models.HTTPServerConfig{Port: 8080}, zerolog.New(os.Stdout), okHandler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if server.ReadHeaderTimeout != defaultReadHeaderTimeout {
+			t.Errorf("unexpected default read header timeout: got %v want %v", server.ReadHeaderTimeout, defaultReadHeaderTimeout)
+		}
+
+		server, err = NewServer(models.HTTPServerConfig{Port: 8080, ReadHeaderTimeoutSec: 2}, zerolog.New(os.Stdout), okHandler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if server.ReadHeaderTimeout != 2*time.Second {
+			t.Errorf("unexpected read header timeout: got %v want %v", server.ReadHeaderTimeout, 2*time.Second)
+		}
+
+		if _, err = NewServer(models.HTTPServerConfig{Port: 8080, ReadHeaderTimeoutSec: -1}, zerolog.New(os.Stdout), okHandler); err == nil {
+			t.Error("expected error for negative read header timeout")
+		}
+	})
+
+	t.Run("writeTimeout", func(t *testing.T) {
+		server, err := NewServer(models.HTTPServerConfig{Port: 8080, WriteTimeoutSec: 60}, zerolog.New(os.Stdout), okHandler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if server.WriteTimeout != time.Minute {
+			t.Errorf("unexpected write timeout: got %v want %v", server.WriteTimeout, time.Minute)
+		}
+
+		if _, err = NewServer(models.HTTPServerConfig{Port: 8080, WriteTimeoutSec: -1}, zerolog.New(os.Stdout), okHandler); err == nil {
+			t.Error("expected error for negative write timeout")
+		}
+	})
+
+	t.Run("maxHeaderBytes", func(t *testing.T) {
+		server, err := NewServer(models.HTTPServerConfig{Port: 8080, MaxHeaderBytes: 1024}, zerolog.New(os.Stdout), okHandler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if server.MaxHeaderBytes != 1024 {
+			t.Errorf("unexpected max header bytes: got %v want %v", server.MaxHeaderBytes, 1024)
+		}
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			_ = server.Serve(listener)
+		}()
+		defer server.Close()
+
+		url := fmt.Sprint("http://", listener.Addr().String(), "/")
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// go's server allows 4096 bytes on top of MaxHeaderBytes
+		req.Header.Set("X-Large", strings.Repeat("a", 8192))
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+			t.Errorf("unexpected status code: got %v want %v", resp.StatusCode, http.StatusRequestHeaderFieldsTooLarge)
+		}
+
+		if _, err = NewServer(models.HTTPServerConfig{Port: 8080, MaxHeaderBytes: -1}, zerolog.New(os.Stdout), okHandler); err == nil {
+			t.Error("expected error for negative max header bytes")
+		}
+	})
+
+	t.Run("tlsMinVersion", func(t *testing.T) {
+		server, err := NewServer(models.HTTPServerConfig{
+			Port:          8443,
+			TLSCertFile:   "cert.pem",
+			TLSKeyFile:    "key.pem",
+			TLSMinVersion: "1.3",
+		}, zerolog.New(os.Stdout), okHandler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if server.TLSConfig.MinVersion != tls.VersionTLS13 {
+			t.Errorf("unexpected tls min version: got %v want %v", server.TLSConfig.MinVersion, tls.VersionTLS13)
+		}
+	})
+
+	t.Run("tlsDefaultMinVersion", func(t *testing.T) {
+		server, err := NewServer(models.HTTPServerConfig{
+			Port:        8443,
+			TLSCertFile: "cert.pem",
+			TLSKeyFile:  "key.pem",
+		}, zerolog.New(os.Stdout), okHandler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if server.TLSConfig.MinVersion != tls.VersionTLS12 {
+			t.Errorf("unexpected tls min version: got %v want %v", server.TLSConfig.MinVersion, tls.VersionTLS12)
+		}
+	})
+
+	t.Run("invalidVersions", func(t *testing.T) {
+		cfgs := []models.HTTPServerConfig{
+			{TLSCertFile: "cert.pem", TLSKeyFile: "key.pem", TLSMinVersion: "0.9"},
+			{TLSCertFile: "cert.pem"},
+			{MinHTTPVersion: "bad"},
+			{MinHTTPVersion: "2.0"},
+		}
+		for _, cfg := range cfgs {
+			if _, err := NewServer(cfg, zerolog.New(os.Stdout), okHandler); err == nil {
+				t.Errorf("expected error for config: %+v", cfg)
+			}
+		}
+	})
+
+	t.Run("rejectsDisallowedProtocol", func(t *testing.T) {
+		server, err := NewServer(models.HTTPServerConfig{MinHTTPVersion: "1.1"}, zerolog.New(os.Stdout), okHandler)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest("GET", "/api/health", nil)
+		req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/1.0", 1, 0
+
+		rr := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusHTTPVersionNotSupported {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusHTTPVersionNotSupported)
+		}
+
+		req = httptest.NewRequest("GET", "/api/health", nil)
+		rr = httptest.NewRecorder()
+		server.Handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+		}
+	})
+}
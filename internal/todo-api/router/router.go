@@ -14,20 +14,46 @@ import (
 	nm "github.com/slok/go-http-metrics/middleware/negroni"
 	"github.com/urfave/negroni"
 
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/admin"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/bodylog"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/compress"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/decompress"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/headers"
 	lHandler "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/logging"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/nostore"
+	oHandler "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/options"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/readiness"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/readonly"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/requestid"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/shed"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/signature"
+	sHandler "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/strict"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/todo"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
 )
 
 // Creates Chi based multiplexer router with middleware
-func NewRouter(cfg models.HTTPRouterConfig, logger zerolog.Logger, todoHandler todo.Handler) *chi.Mux {
+func NewRouter(cfg models.HTTPRouterConfig, logger zerolog.Logger, render renderer.Renderer, gate *readiness.Gate,
+	checker *readiness.Checker, readOnly *readonly.Mode, advisor admin.IndexAdvisor, todoHandler todo.Handler) (*chi.Mux, error) {
+	cHandler, err := compress.NewHandlerFunc(cfg.CompressionLevel)
+	if err != nil {
+		return nil, err
+	}
+
 	r := chi.NewRouter()
 
-	r.Use(middleware.RequestID)
+	r.Use(oHandler.NewHandlerFunc(cfg.AllowedMethods))
+	r.Use(requestid.NewHandlerFunc(cfg.RequestIDHeaders, cfg.RequestIDResponseHeader))
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Recoverer)
 	r.Use(lHandler.NewHandlerFunc(logger))
 	r.Use(middleware.Timeout(time.Duration(cfg.TimeoutSec) * time.Second))
+	r.Use(cHandler)
+	r.Use(nostore.NewHandlerFunc(cfg.NoStorePaths))
+	if cfg.StrictMode {
+		r.Use(sHandler.NewHandlerFunc(logger, render))
+	}
 
 	httpMw := httpMiddleware.New(httpMiddleware.Config{
 		DisableMeasureInflight: true,
@@ -42,21 +68,60 @@ func NewRouter(cfg models.HTTPRouterConfig, logger zerolog.Logger, todoHandler t
 	}))
 
 	r.Route("/api", func(r chi.Router) {
-		r.Route("/todo", func(r chi.Router) {
-			r.Route("/{id}", func(r chi.Router) {
-				idMetricHandler := nm.Handler("/api/todo/{id}", httpMw)
-				r.Get("/", negroni.New(idMetricHandler, negroni.WrapFunc(todoHandler.Get)).ServeHTTP)
-				r.Delete("/", negroni.New(idMetricHandler, negroni.WrapFunc(todoHandler.Delete)).ServeHTTP)
+		r.Use(readonly.NewHandlerFunc(logger, render, readOnly))
+		r.Group(func(r chi.Router) {
+			r.Use(shed.NewHandlerFunc(logger, render, cfg.ShedInFlightThreshold))
+			r.Use(headers.NewHandlerFunc(logger, render, cfg.RequiredHeaders))
+			r.Use(decompress.NewHandlerFunc(logger, render, cfg.MaxDecompressedBodyBytes))
+			// verified before body logging so bodies of unauthenticated requests aren't logged
+			if cfg.SigningSecret != "" {
+				r.Use(signature.NewHandlerFunc(logger, render, cfg.SigningSecret, time.Duration(cfg.SigningMaxSkewSec)*time.Second))
+			}
+			r.Use(bodylog.NewHandlerFunc(cfg.LogBodyBytes))
+			r.Route("/todo", func(r chi.Router) {
+				r.Use(readiness.NewHandlerFunc(logger, render, gate))
+				r.Route("/{id}", func(r chi.Router) {
+					idMetricHandler := nm.Handler("/api/todo/{id}", httpMw)
+					r.Get("/", negroni.New(idMetricHandler, negroni.WrapFunc(todoHandler.Get)).ServeHTTP)
+					r.Delete("/", negroni.New(idMetricHandler, negroni.WrapFunc(todoHandler.Delete)).ServeHTTP)
+					r.Get("/neighbors", negroni.New(nm.Handler("/api/todo/{id}/neighbors", httpMw), negroni.WrapFunc(todoHandler.Neighbors)).ServeHTTP)
+					r.Post("/touch", negroni.New(nm.Handler("/api/todo/{id}/touch", httpMw), negroni.WrapFunc(todoHandler.Touch)).ServeHTTP)
+					r.Post("/attempts", negroni.New(nm.Handler("/api/todo/{id}/attempts", httpMw), negroni.WrapFunc(todoHandler.Attempts)).ServeHTTP)
+					if cfg.ShareSecret != "" {
+						shareMetricHandler := nm.Handler("/api/todo/{id}/share", httpMw)
+						r.Post("/share", negroni.New(shareMetricHandler, negroni.WrapFunc(todoHandler.Share)).ServeHTTP)
+						r.Delete("/share", negroni.New(shareMetricHandler, negroni.WrapFunc(todoHandler.RevokeShares)).ServeHTTP)
+					}
+				})
+				r.Post("/", negroni.New(nm.Handler("/api/todo", httpMw), negroni.WrapFunc(todoHandler.Post)).ServeHTTP)
+				r.Get("/changes", negroni.New(nm.Handler("/api/todo/changes", httpMw), negroni.WrapFunc(todoHandler.Changes)).ServeHTTP)
 			})
-			r.Post("/", negroni.New(nm.Handler("/api/todo", httpMw), negroni.WrapFunc(todoHandler.Post)).ServeHTTP)
+			r.Get("/capabilities", negroni.New(nm.Handler("/api/capabilities", httpMw), negroni.WrapFunc(todoHandler.Capabilities)).ServeHTTP)
 		})
 		r.Get("/health", func(w http.ResponseWriter, _ *http.Request) {
 			w.WriteHeader(http.StatusOK)
 		})
+		r.Get("/ready", readiness.NewReadyHandler(logger, render, checker))
+	})
+
+	// share links are public, they're authorized by the signed token instead of the API middleware
+	if cfg.ShareSecret != "" {
+		r.Route("/shared", func(r chi.Router) {
+			r.Use(readiness.NewHandlerFunc(logger, render, gate))
+			r.Get("/{token}", negroni.New(nm.Handler("/shared/{token}", httpMw), negroni.WrapFunc(todoHandler.Shared)).ServeHTTP)
+		})
+	}
+
+	// admin routes only use their own auth, so they're reachable in read-only mode to toggle it
+	adminHandler := admin.NewHandler(logger, render, readOnly, advisor)
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(admin.NewHandlerFunc(logger, render, cfg.AdminToken))
+		r.Put("/readonly", negroni.New(nm.Handler("/admin/readonly", httpMw), negroni.WrapFunc(adminHandler.PutReadOnly)).ServeHTTP)
+		r.Get("/index-advice", negroni.New(nm.Handler("/admin/index-advice", httpMw), negroni.WrapFunc(adminHandler.GetIndexAdvice)).ServeHTTP)
 	})
 
 	r.Route("/metrics", func(r chi.Router) {
 		r.Get("/", promhttp.Handler().ServeHTTP)
 	})
-	return r
+	return r, nil
 }
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
+)
+
+// selfTestMarker prefixes the todo written by the start up self-test so a leftover row is unmistakable
+const selfTestMarker = "__todo-api-self-test__"
+
+// selfTestConnector runs the start up self-test after each successful connect, a failed self-test is treated like a
+// failed connect so it's retried and keeps the gate closed the same way
+type selfTestConnector struct {
+	connector
+	store todo.TodoStore
+}
+
+func (c selfTestConnector) Connect() error {
+	if err := c.connector.Connect(); err != nil {
+		return err
+	}
+	return selfTest(context.Background(), c.store)
+}
+
+// selfTest writes a throwaway todo, reads it back and deletes it to catch permission or schema issues before serving
+// traffic, the todo is deleted even when reading it back fails
+func selfTest(ctx context.Context, store todo.TodoStore) (err error) {
+	text := fmt.Sprint(selfTestMarker, " ", time.Now().UnixNano())
+	inserted, err := store.PostTodo(ctx, models.TodoItem{Todo: text, CreatedOn: time.Now()})
+	if err != nil {
+		return errors.Wrap(err, "self-test failed to write todo")
+	}
+	defer func() {
+		count, dErr := store.DeleteTodo(ctx, inserted.ID)
+		if err == nil && dErr != nil {
+			err = errors.Wrap(dErr, "self-test failed to delete todo")
+		} else if err == nil && count == 0 {
+			err = errors.New("self-test failed to delete todo, no rows deleted")
+		}
+	}()
+
+	result, found, err := store.GetTodo(ctx, inserted.ID)
+	if err != nil {
+		return errors.Wrap(err, "self-test failed to read todo")
+	}
+	if !found || !strings.HasPrefix(result.Todo, selfTestMarker) || result.Todo != text {
+		return errors.New("self-test read back a different todo than it wrote")
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/readiness"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/mocks"
+)
+
+func TestSelfTest(t *testing.T) {
+	isSelfTestTodo := mock.MatchedBy(func(item models.TodoItem) bool {
+		return strings.HasPrefix(item.Todo, selfTestMarker)
+	})
+
+	t.Run("roundTrip", func(t *testing.T) {
+		store := &mocks.TodoStore{}
+		var written string
+		store.On("PostTodo", mock.Anything, isSelfTestTodo).Run(func(args mock.Arguments) {
+			written = args.Get(1).(models.TodoItem).Todo
+		}).Return(models.TodoItem{ID: 1}, nil)
+		store.On("GetTodo", mock.Anything, 1).Return(func(context.Context, int) models.TodoItem {
+			return models.TodoItem{ID: 1, Todo: written}
+		}, true, nil)
+		store.On("DeleteTodo", mock.Anything, 1).Return(1, nil)
+
+		if err := selfTest(context.Background(), store); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		store.AssertExpectations(t)
+	})
+
+	t.Run("brokenStore", func(t *testing.T) {
+		store := &mocks.TodoStore{}
+		store.On("PostTodo", mock.Anything, isSelfTestTodo).Return(models.TodoItem{}, errors.New("permission denied"))
+
+		if err := selfTest(context.Background(), store); err == nil {
+			t.Error("expected self-test to fail")
+		}
+		store.AssertNotCalled(t, "DeleteTodo", mock.Anything, mock.Anything)
+	})
+
+	t.Run("cleanedUpWhenReadFails", func(t *testing.T) {
+		store := &mocks.TodoStore{}
+		store.On("PostTodo", mock.Anything, isSelfTestTodo).Return(models.TodoItem{ID: 1}, nil)
+		store.On("GetTodo", mock.Anything, 1).Return(models.TodoItem{}, false, nil)
+		store.On("DeleteTodo", mock.Anything, 1).Return(1, nil)
+
+		if err := selfTest(context.Background(), store); err == nil {
+			t.Error("expected self-test to fail")
+		}
+		store.AssertCalled(t, "DeleteTodo", mock.Anything, 1)
+	})
+
+	t.Run("failsReadiness", func(t *testing.T) {
+		store := &mocks.TodoStore{}
+		store.On("PostTodo", mock.Anything, isSelfTestTodo).Return(models.TodoItem{}, errors.New("relation \"todo\" does not exist"))
+
+		gate := &readiness.Gate{}
+		client := selfTestConnector{connector: &failingConnector{}, store: store}
+		err := connectDatabase(context.Background(), failFastMode, time.Millisecond, retryPolicy{}, zerolog.New(os.Stdout), client, gate)
+		if err == nil {
+			t.Error("expected connect to fail")
+		}
+		if gate.IsReady() {
+			t.Error("expected gate to stay closed")
+		}
+	})
+}
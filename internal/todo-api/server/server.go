@@ -2,21 +2,66 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	"github.com/unrolled/render"
 
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/clients/postgres"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/readiness"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/readonly"
 	todoHandler "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/todo"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/processes/http"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/router"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
+	"github.com/alexsniffin/go-api-starter/pkg/renderer"
 )
 
+const (
+	failFastMode = "fail_fast"
+	degradedMode = "degraded"
+
+	// defaultConnectRetryMaxWait and defaultConnectRetryBackoff apply when the config leaves them at zero, so a missing
+	// key doesn't disable retrying
+	defaultConnectRetryMaxWait = 30 * time.Second
+	defaultConnectRetryBackoff = time.Second
+)
+
+// retryPolicy is the exponential backoff used to connect at start up, a zero maxWait disables retrying
+type retryPolicy struct {
+	backoff time.Duration
+	maxWait time.Duration
+}
+
+// newRetryPolicy returns the start up retry policy for the config, zero values use the defaults and a negative
+// ConnectRetryMaxWaitSec disables retrying
+func newRetryPolicy(cfg models.DatabaseConfig) retryPolicy {
+	retry := retryPolicy{
+		backoff: time.Duration(cfg.ConnectRetryBackoffSec) * time.Second,
+		maxWait: time.Duration(cfg.ConnectRetryMaxWaitSec) * time.Second,
+	}
+	if cfg.ConnectRetryMaxWaitSec < 0 {
+		return retryPolicy{}
+	}
+	if retry.maxWait == 0 {
+		retry.maxWait = defaultConnectRetryMaxWait
+	}
+	if retry.backoff == 0 {
+		retry.backoff = defaultConnectRetryBackoff
+	}
+	return retry
+}
+
+// connector is a dependency which has to connect before the server can serve requests with it
+type connector interface {
+	Connect() error
+}
+
 // Server handles the runtime of the application.
 type Server struct {
 	cfg    models.Config
@@ -24,32 +69,78 @@ type Server struct {
 
 	httpServer *http.Server
 	pgClient   postgres.Client
+	events     *events.Bus
+	readOnly   *readonly.Mode
 
+	cancel     context.CancelFunc
 	fatalErrCh chan error
 	shutdown   sync.Once
 }
 
 // NewServer creates a new server instance with dependencies.
 func NewServer(cfg models.Config, logger zerolog.Logger) *Server {
-	// set up pg client
-	newPgClient, err := postgres.NewClient(logger, cfg.Database)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// set up pg client, depending on the configured mode a failure either stops the server or starts it degraded
+	newGate := &readiness.Gate{}
+	newPgClient := postgres.NewClient(logger, cfg.Database)
+	reconnectInterval := time.Duration(cfg.Database.ReconnectIntervalSec) * time.Second
+	retry := newRetryPolicy(cfg.Database)
+	if err := todoHandler.ValidateZeroTimestamps(cfg.HTTPRouter.ZeroTimestamps); err != nil {
+		logger.Panic().Caller().Err(err).Msg("failed to initialize todo handler")
+	}
+	newTodoStore := todo.NewStore(newPgClient)
+	var pgConnector connector = &newPgClient
+	if cfg.Database.StartupSelfTest {
+		pgConnector = selfTestConnector{connector: pgConnector, store: &newTodoStore}
+	}
+	err := connectDatabase(ctx, cfg.Database.OnInitFailure, reconnectInterval, retry, logger, pgConnector, newGate)
 	if err != nil {
 		logger.Panic().Caller().Err(err).Msg("failed to initialize pg client")
 	}
 
 	// set up store and handler
-	newTodoStore := todo.NewStore(newPgClient)
-	newTodoHandler := todoHandler.NewHandler(logger, render.New(), newTodoStore)
+	newRender, err := renderer.NewRenderer(cfg.Renderer)
+	if err != nil {
+		logger.Panic().Caller().Err(err).Msg("failed to initialize renderer")
+	}
+	newEvents, err := events.NewBus(cfg.Events.Dispatch)
+	if err != nil {
+		logger.Panic().Caller().Err(err).Msg("failed to initialize event bus")
+	}
+	newTodoHandler := todoHandler.NewHandler(logger, newRender, newTodoStore, todoHandler.NoopValidator{}, newEvents,
+		todoHandler.Options{
+			StrictMode:        cfg.HTTPRouter.StrictMode,
+			DedupeWindow:      time.Duration(cfg.HTTPRouter.DedupeWindowSec) * time.Second,
+			DatabaseCreatedOn: cfg.Database.DatabaseCreatedOn,
+			ShareSecret:       cfg.HTTPRouter.ShareSecret,
+			ShareTTL:          time.Duration(cfg.HTTPRouter.ShareTTLSec) * time.Second,
+			ZeroTimestamps:    cfg.HTTPRouter.ZeroTimestamps,
+			PostCreated:       cfg.HTTPRouter.PostCreated,
+			MaxChangesWait:    todoHandler.MaxChangesWait(time.Duration(cfg.HTTPRouter.TimeoutSec) * time.Second),
+		})
 
 	// set up router and HTTP server
-	newRouter := router.NewRouter(cfg.HTTPRouter, logger, newTodoHandler)
-	newHTTPServer := http.NewServer(cfg.HTTPServer, logger, newRouter)
+	newReadOnly := &readonly.Mode{}
+	newReadOnly.SetEnabled(cfg.HTTPRouter.ReadOnly)
+	newChecker := readiness.NewChecker(newGate, &newPgClient, time.Duration(cfg.HTTPRouter.ReadinessCacheTTLSec)*time.Second)
+	newRouter, err := router.NewRouter(cfg.HTTPRouter, logger, newRender, newGate, newChecker, newReadOnly, &newTodoStore, newTodoHandler)
+	if err != nil {
+		logger.Panic().Caller().Err(err).Msg("failed to initialize router")
+	}
+	newHTTPServer, err := http.NewServer(cfg.HTTPServer, logger, newRouter)
+	if err != nil {
+		logger.Panic().Caller().Err(err).Msg("failed to initialize http server")
+	}
 
 	return &Server{
 		cfg:        cfg,
 		logger:     logger,
 		httpServer: newHTTPServer,
 		pgClient:   newPgClient,
+		events:     newEvents,
+		readOnly:   newReadOnly,
+		cancel:     cancel,
 		fatalErrCh: make(chan error),
 	}
 }
@@ -66,6 +157,13 @@ func (s *Server) Start() {
 	}
 }
 
+// Reload applies the hot reloadable settings of cfg to the running server, currently HTTPRouter.ReadOnly. Every other
+// setting requires a restart.
+func (s *Server) Reload(cfg models.Config) {
+	s.readOnly.SetEnabled(cfg.HTTPRouter.ReadOnly)
+	s.logger.Info().Bool("read_only", cfg.HTTPRouter.ReadOnly).Msg("reloaded configuration")
+}
+
 // Shutdown signals the shutdown process across all processes in the server.
 func (s *Server) Shutdown(fromErr bool) {
 	s.shutdown.Do(func() {
@@ -86,6 +184,9 @@ func (s *Server) Shutdown(fromErr bool) {
 			}
 		}(graceful)
 
+		// stop background processes, e.g. reconnecting to pg in degraded mode
+		s.cancel()
+
 		// shutdown http server first to prevent new requests
 		err := s.httpServer.Shutdown(ctx)
 		if err != nil {
@@ -94,6 +195,9 @@ func (s *Server) Shutdown(fromErr bool) {
 			s.logger.Info().Msg("shutdown http server gracefully")
 		}
 
+		// dispatch events still queued from the last requests
+		s.events.Close()
+
 		err = s.pgClient.Shutdown()
 		if err != nil {
 			s.logger.Error().Caller().Err(err).Msg("failed to shutdown postgres gracefully")
@@ -110,3 +214,81 @@ func (s *Server) Shutdown(fromErr bool) {
 		}
 	})
 }
+
+// connectDatabase connects the client, retrying with the policy, and opens the gate. If the connection still fails in
+// `fail_fast` mode the error is returned, in `degraded` mode the gate stays closed while the client reconnects in the
+// background until it succeeds or the context is done.
+func connectDatabase(ctx context.Context, mode string, interval time.Duration, retry retryPolicy, logger zerolog.Logger,
+	client connector, gate *readiness.Gate) error {
+	if mode != "" && mode != failFastMode && mode != degradedMode {
+		return errors.New(fmt.Sprintf("unsupported OnInitFailure mode: %s", mode))
+	}
+	if mode == degradedMode && interval <= 0 {
+		return errors.New("ReconnectIntervalSec must be positive in degraded mode")
+	}
+	if retry.maxWait > 0 && retry.backoff <= 0 {
+		return errors.New("ConnectRetryBackoffSec must be positive when retrying")
+	}
+
+	err := connectWithRetry(ctx, retry, logger, client)
+	if err == nil {
+		gate.SetReady(true)
+		return nil
+	}
+	if mode != degradedMode {
+		return err
+	}
+
+	logger.Warn().Err(err).Msg("failed to connect to pg, starting in degraded mode")
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := client.Connect(); err != nil {
+					logger.Warn().Err(err).Msg("failed to reconnect to pg, still in degraded mode")
+					continue
+				}
+				logger.Info().Msg("reconnected to pg, leaving degraded mode")
+				gate.SetReady(true)
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// connectWithRetry connects the client, doubling the backoff after each failed attempt until it succeeds, the policy's
+// maxWait has elapsed or the context is done
+func connectWithRetry(ctx context.Context, retry retryPolicy, logger zerolog.Logger, client connector) error {
+	deadline := time.Now().Add(retry.maxWait)
+	backoff := retry.backoff
+
+	for attempt := 1; ; attempt++ {
+		err := client.Connect()
+		if err == nil {
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if retry.maxWait <= 0 || remaining <= 0 {
+			return err
+		}
+		if backoff > remaining {
+			backoff = remaining
+		}
+
+		logger.Warn().Err(err).Int("attempt", attempt).Dur("backoff", backoff).Msg("failed to connect to pg, retrying")
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/readiness"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// failingConnector fails to connect the given number of times before succeeding
+type failingConnector struct {
+	failures int32
+	calls    int32
+}
+
+func (f *failingConnector) Connect() error {
+	if atomic.AddInt32(&f.calls, 1) <= f.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestConnectDatabase(t *testing.T) {
+	logger := zerolog.New(os.Stdout)
+
+	t.Run("connected", func(t *testing.T) {
+		gate := &readiness.Gate{}
+		err := connectDatabase(context.Background(), failFastMode, time.Millisecond, retryPolicy{}, logger, &failingConnector{}, gate)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !gate.IsReady() {
+			t.Error("expected gate to be ready")
+		}
+	})
+
+	t.Run("failFast", func(t *testing.T) {
+		gate := &readiness.Gate{}
+		connector := &failingConnector{failures: 1}
+		err := connectDatabase(context.Background(), failFastMode, time.Millisecond, retryPolicy{}, logger, connector, gate)
+		if err == nil {
+			t.Error("expected error")
+		}
+		if gate.IsReady() {
+			t.Error("expected gate not to be ready")
+		}
+	})
+
+	t.Run("degraded", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		gate := &readiness.Gate{}
+		connector := &failingConnector{failures: 3}
+		err := connectDatabase(ctx, degradedMode, time.Millisecond, retryPolicy{}, logger, connector, gate)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			t.FailNow()
+		}
+		if gate.IsReady() {
+			t.Error("expected gate not to be ready before reconnecting")
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for !gate.IsReady() && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if !gate.IsReady() {
+			t.Error("expected gate to be ready after reconnecting")
+		}
+		if calls := atomic.LoadInt32(&connector.calls); calls != 4 {
+			t.Errorf("unexpected connect calls: got %v want %v", calls, 4)
+		}
+	})
+
+	t.Run("retryUntilConnected", func(t *testing.T) {
+		gate := &readiness.Gate{}
+		connector := &failingConnector{failures: 3}
+		retry := retryPolicy{backoff: time.Millisecond, maxWait: time.Second}
+		err := connectDatabase(context.Background(), failFastMode, time.Millisecond, retry, logger, connector, gate)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !gate.IsReady() {
+			t.Error("expected gate to be ready")
+		}
+		if calls := atomic.LoadInt32(&connector.calls); calls != 4 {
+			t.Errorf("unexpected connect calls: got %v want %v", calls, 4)
+		}
+	})
+
+	t.Run("retryGivesUp", func(t *testing.T) {
+		gate := &readiness.Gate{}
+		connector := &failingConnector{failures: 1000}
+		retry := retryPolicy{backoff: time.Millisecond, maxWait: 20 * time.Millisecond}
+		err := connectDatabase(context.Background(), failFastMode, time.Millisecond, retry, logger, connector, gate)
+		if err == nil {
+			t.Error("expected error")
+		}
+		if gate.IsReady() {
+			t.Error("expected gate not to be ready")
+		}
+		if calls := atomic.LoadInt32(&connector.calls); calls < 2 {
+			t.Errorf("expected connect to be retried: got %v calls", calls)
+		}
+	})
+
+	t.Run("retryWithoutBackoff", func(t *testing.T) {
+		retry := retryPolicy{maxWait: time.Second}
+		err := connectDatabase(context.Background(), failFastMode, time.Millisecond, retry, logger, &failingConnector{}, &readiness.Gate{})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("retryPolicyDefaults", func(t *testing.T) {
+		cases := []struct {
+			name     string
+			cfg      models.DatabaseConfig
+			expected retryPolicy
+		}{
+			{"missing", models.DatabaseConfig{}, retryPolicy{backoff: time.Second, maxWait: 30 * time.Second}},
+			{"configured", models.DatabaseConfig{ConnectRetryMaxWaitSec: 10, ConnectRetryBackoffSec: 2},
+				retryPolicy{backoff: 2 * time.Second, maxWait: 10 * time.Second}},
+			{"disabled", models.DatabaseConfig{ConnectRetryMaxWaitSec: -1}, retryPolicy{}},
+		}
+		for _, c := range cases {
+			if result := newRetryPolicy(c.cfg); result != c.expected {
+				t.Errorf("unexpected retry policy for %v: got %+v want %+v", c.name, result, c.expected)
+			}
+		}
+	})
+
+	t.Run("unsupportedMode", func(t *testing.T) {
+		err := connectDatabase(context.Background(), "unknown", time.Millisecond, retryPolicy{}, logger, &failingConnector{}, &readiness.Gate{})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package todo
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/context"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// todoTable is the table of the TodoItem model as the ORM names it
+var todoTable = orm.GetTable(reflect.TypeOf(models.TodoItem{})).Name
+
+// queriedColumns are the todo columns the store's queries filter or sort on besides the id primary key
+var queriedColumns = []string{"created_on", "todo"}
+
+// indexedColumnsQuery selects the leading column of every index on the table ?0
+const indexedColumnsQuery = `SELECT a.attname FROM pg_index AS i
+JOIN pg_attribute AS a ON a.attrelid = i.indrelid AND a.attnum = i.indkey[0]
+WHERE i.indrelid = ?0::regclass`
+
+// statementsQuery selects the recorded statements matching the pattern ?0 with their number of calls
+const statementsQuery = `SELECT query, calls FROM pg_stat_statements WHERE query ~* ?0`
+
+// sqlToken matches a quoted identifier, a string literal, a keyword or identifier, or a single other character
+var sqlToken = regexp.MustCompile(`"(?:[^"]|"")*"|'(?:[^']|'')*'|[A-Za-z_][A-Za-z0-9_$]*|[^\sA-Za-z_]`)
+
+// filterClauses maps the keywords starting a clause to whether the clause filters or sorts rows, `by` is handled
+// separately since only ORDER BY sorts
+var filterClauses = map[string]bool{
+	"where":     true,
+	"on":        true,
+	"select":    false,
+	"from":      false,
+	"join":      false,
+	"update":    false,
+	"into":      false,
+	"set":       false,
+	"values":    false,
+	"returning": false,
+	"limit":     false,
+	"offset":    false,
+	"having":    false,
+}
+
+type statementStats struct {
+	Query string
+	Calls int64
+}
+
+// IndexAdvice reports the queried columns of the todo table without an index starting with them. The advice uses
+// pg_stat_statements to leave out columns which aren't being queried, without it the advice is degraded to every
+// column the store uses.
+func (s *Store) IndexAdvice(ctx context.Context) (models.IndexAdvice, error) {
+	log.Ctx(ctx).Debug().Caller().Msg("index advice db request")
+
+	db := s.pgClient.GetConnection()
+	var indexed pg.Strings
+	if _, err := db.QueryContext(ctx, &indexed, indexedColumnsQuery, todoTable); err != nil {
+		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to get indexed columns from db")
+		return models.IndexAdvice{}, classifyError(ctx, err)
+	}
+
+	var statements []statementStats
+	var calls map[string]int64
+	tablePattern := fmt.Sprintf(`\m%s\M`, todoTable)
+	if _, err := db.QueryContext(ctx, &statements, statementsQuery, tablePattern); err != nil {
+		if ctx.Err() != nil {
+			return models.IndexAdvice{}, classifyError(ctx, err)
+		}
+		// the extension is missing or not preloaded
+		log.Ctx(ctx).Warn().Err(err).Caller().Msg("pg_stat_statements isn't available, degrading index advice")
+	} else {
+		calls = columnCalls(statements)
+	}
+
+	return indexAdvice(indexed, calls), nil
+}
+
+// columnCalls sums the calls of the statements filtering or sorting on each of the queriedColumns
+func columnCalls(statements []statementStats) map[string]int64 {
+	calls := make(map[string]int64, len(queriedColumns))
+	for _, statement := range statements {
+		used := filterColumns(statement.Query)
+		for _, column := range queriedColumns {
+			if used[column] {
+				calls[column] += statement.Calls
+			}
+		}
+	}
+	return calls
+}
+
+// filterColumns returns the columns referenced in the WHERE, ON and ORDER BY clauses of query. Table names only
+// appear after FROM, JOIN, UPDATE or INTO and qualifiers are followed by a dot, so a column named like its table is
+// only counted where it's used as a column.
+func filterColumns(query string) map[string]bool {
+	used := map[string]bool{}
+	inFilter := false
+	previous := ""
+	tokens := sqlToken.FindAllString(query, -1)
+	for i, token := range tokens {
+		word := strings.ToLower(token)
+		switch {
+		case strings.HasPrefix(token, "'"):
+			continue
+		case strings.HasPrefix(token, `"`):
+			word = strings.ReplaceAll(token[1:len(token)-1], `""`, `"`)
+		case word == "by":
+			inFilter = previous == "order"
+			previous = word
+			continue
+		default:
+			if filter, ok := filterClauses[word]; ok {
+				inFilter = filter
+				previous = word
+				continue
+			}
+		}
+		previous = word
+
+		if inFilter && (i+1 == len(tokens) || tokens[i+1] != ".") {
+			used[word] = true
+		}
+	}
+	return used
+}
+
+// indexAdvice builds the advice for the queried columns which aren't in indexed, a nil calls means the statement
+// statistics aren't available
+func indexAdvice(indexed []string, calls map[string]int64) models.IndexAdvice {
+	isIndexed := make(map[string]bool, len(indexed))
+	for _, column := range indexed {
+		isIndexed[column] = true
+	}
+
+	advice := models.IndexAdvice{
+		Degraded: calls == nil,
+		Missing:  []models.ColumnAdvice{},
+	}
+	for _, column := range queriedColumns {
+		if isIndexed[column] {
+			continue
+		}
+		columnAdvice := models.ColumnAdvice{
+			Table:      todoTable,
+			Column:     column,
+			Suggestion: fmt.Sprintf("CREATE INDEX CONCURRENTLY ON %s (%s)", todoTable, column),
+		}
+		if calls != nil {
+			count := calls[column]
+			if count == 0 {
+				continue
+			}
+			columnAdvice.Calls = &count
+		}
+		advice.Missing = append(advice.Missing, columnAdvice)
+	}
+	return advice
+}
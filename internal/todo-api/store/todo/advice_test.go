@@ -0,0 +1,103 @@
+package todo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-pg/pg"
+
+	"github.com/alexsniffin/go-api-starter/mocks"
+)
+
+func TestIndexAdvice(t *testing.T) {
+	t.Run("degraded", func(t *testing.T) {
+		advice := indexAdvice([]string{"id", "todo"}, nil)
+
+		if !advice.Degraded {
+			t.Error("expected degraded advice")
+		}
+		if len(advice.Missing) != 1 || advice.Missing[0].Column != "created_on" || advice.Missing[0].Calls != nil {
+			t.Errorf("unexpected missing indexes: got %+v", advice.Missing)
+		}
+	})
+
+	t.Run("statements", func(t *testing.T) {
+		advice := indexAdvice([]string{"id"}, map[string]int64{"created_on": 12})
+
+		if advice.Degraded {
+			t.Error("expected advice from statement statistics")
+		}
+		if len(advice.Missing) != 1 || advice.Missing[0].Column != "created_on" || *advice.Missing[0].Calls != 12 {
+			t.Errorf("unexpected missing indexes: got %+v", advice.Missing)
+		}
+	})
+
+	t.Run("columnNamedLikeTable", func(t *testing.T) {
+		statements := []statementStats{
+			{`SELECT "todo_item"."id", "todo_item"."todo" FROM "todo_items" AS "todo_item" WHERE (id = $1)`, 5},
+			{`INSERT INTO "todo_items" ("todo", "created_on") VALUES ($1, $2) RETURNING "id"`, 7},
+			{`SELECT t.* FROM "todo_items" AS t WHERE t.id = $1 ORDER BY t.created_on`, 3},
+			{`SELECT "todo_item"."id" FROM "todo_items" AS "todo_item" WHERE (todo = $1) LIMIT $2`, 2},
+		}
+
+		calls := columnCalls(statements)
+		if calls["todo"] != 2 {
+			t.Errorf("unexpected calls for todo: got %v want %v", calls["todo"], 2)
+		}
+		if calls["created_on"] != 3 {
+			t.Errorf("unexpected calls for created_on: got %v want %v", calls["created_on"], 3)
+		}
+	})
+
+	t.Run("suggestsModelTable", func(t *testing.T) {
+		advice := indexAdvice([]string{"id", "todo"}, nil)
+
+		expected := "CREATE INDEX CONCURRENTLY ON todo_items (created_on)"
+		if advice.Missing[0].Table != "todo_items" || advice.Missing[0].Suggestion != expected {
+			t.Errorf("unexpected advice: got %+v want table %v and suggestion %v", advice.Missing[0], "todo_items",
+				expected)
+		}
+	})
+}
+
+func TestStore_IndexAdvice_QueryFailure(t *testing.T) {
+	t.Parallel()
+
+	// nothing listens on the port so the indexes can't be read
+	db := pg.Connect(&pg.Options{Addr: "127.0.0.1:1"})
+	defer db.Close()
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+	dbMock.On("GetConnection").Return(db)
+
+	if _, err := todoStore.IndexAdvice(context.Background()); err == nil {
+		t.Error("expected error when the indexes can't be read")
+	}
+}
+
+func TestStore_IndexAdvice_WithoutStatements(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+	dbMock.On("GetConnection").Return(db)
+
+	// the test container doesn't preload pg_stat_statements so the advice degrades instead of failing
+	advice, err := todoStore.IndexAdvice(context.Background())
+	unexpected(t, err)
+	if !advice.Degraded {
+		t.Error("expected degraded advice without pg_stat_statements")
+	}
+	if len(advice.Missing) != len(queriedColumns) {
+		t.Errorf("unexpected missing indexes: got %+v want %v", advice.Missing, queriedColumns)
+	}
+}
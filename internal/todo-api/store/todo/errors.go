@@ -0,0 +1,84 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-pg/pg"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// Postgres constraint violation codes, https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	stringTooLong       = "22001"
+	notNullViolation    = "23502"
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+	checkViolation      = "23514"
+)
+
+// poolTimeoutMessage is the error returned by go-pg when no connection is free within the pool timeout, the error
+// itself is internal to go-pg
+const poolTimeoutMessage = "pg: connection pool timeout"
+
+var (
+	// ErrPoolExhausted is returned when a query timed out waiting for a connection, as opposed to the query timing out
+	ErrPoolExhausted = errors.New("database connection pool exhausted")
+	// ErrNotFound is returned when a write targets a todo which doesn't exist
+	ErrNotFound = errors.New("todo not found")
+	// ErrInvalidSort is returned for a sort order the store doesn't support
+	ErrInvalidSort = errors.New("sort must be one of id or created_on")
+	// ErrInvalidField is returned for a field the store doesn't count distinct values of
+	ErrInvalidField = errors.New("field must be attempts")
+	// ErrHighCardinalityField is returned for a field whose values are nearly all distinct, e.g. id
+	ErrHighCardinalityField = errors.New("field has too many distinct values to count")
+	// ErrDuplicate is returned when a write violates a unique constraint
+	ErrDuplicate = errors.New("todo already exists")
+	// ErrForeignKey is returned when a write references a record which doesn't exist
+	ErrForeignKey = errors.New("todo references a record which doesn't exist")
+	// ErrNotNull is returned when a write is missing a required field
+	ErrNotNull = errors.New("todo is missing a required field")
+	// ErrCheck is returned when a write fails a check constraint
+	ErrCheck = errors.New("todo has an invalid field value")
+	// ErrTooLong is returned when a field is longer than its column
+	ErrTooLong = errors.New(fmt.Sprint("todo must be no more than ", models.TodoMaxLength, " characters"))
+)
+
+// classifyError maps pool exhaustion and Postgres constraint violations to the store's errors, the constraint name is
+// kept in the message for logging. When ctx is done go-pg cancels the query server side and returns the resulting
+// Postgres error, it's mapped to the ctx error so callers can tell a cancelled request from a failed query. Any other
+// error is returned unchanged.
+func classifyError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("%w: %s", ctx.Err(), err)
+	}
+	if err != nil && err.Error() == poolTimeoutMessage {
+		poolExhausted.Inc()
+		return ErrPoolExhausted
+	}
+
+	pgErr, ok := err.(pg.Error)
+	if !ok {
+		return err
+	}
+
+	var classified error
+	switch pgErr.Field('C') {
+	case stringTooLong:
+		classified = ErrTooLong
+	case notNullViolation:
+		classified = ErrNotNull
+	case foreignKeyViolation:
+		classified = ErrForeignKey
+	case uniqueViolation:
+		classified = ErrDuplicate
+	case checkViolation:
+		classified = ErrCheck
+	default:
+		return err
+	}
+
+	return fmt.Errorf("%w: %s", classified, pgErr.Field('n'))
+}
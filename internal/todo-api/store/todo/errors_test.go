@@ -0,0 +1,91 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakePgError implements pg.Error with a fixed SQLSTATE code
+type fakePgError struct {
+	code string
+}
+
+func (e fakePgError) Error() string {
+	return "ERROR #" + e.code
+}
+
+func (e fakePgError) Field(field byte) string {
+	switch field {
+	case 'C':
+		return e.code
+	case 'n':
+		return "todo_constraint"
+	}
+	return ""
+}
+
+func (e fakePgError) IntegrityViolation() bool {
+	return strings.HasPrefix(e.code, "23")
+}
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		name     string
+		code     string
+		expected error
+	}{
+		{"tooLong", "22001", ErrTooLong},
+		{"notNull", "23502", ErrNotNull},
+		{"foreignKey", "23503", ErrForeignKey},
+		{"unique", "23505", ErrDuplicate},
+		{"check", "23514", ErrCheck},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := classifyError(context.Background(), fakePgError{code: c.code})
+			if !errors.Is(err, c.expected) {
+				t.Errorf("unexpected error: got %v want %v", err, c.expected)
+			}
+		})
+	}
+
+	t.Run("poolExhausted", func(t *testing.T) {
+		before := testutil.ToFloat64(poolExhausted)
+
+		err := classifyError(context.Background(), errors.New("pg: connection pool timeout"))
+		if err != ErrPoolExhausted {
+			t.Errorf("unexpected error: got %v want %v", err, ErrPoolExhausted)
+		}
+		if count := testutil.ToFloat64(poolExhausted); count != before+1 {
+			t.Errorf("unexpected pool exhausted count: got %v want %v", count, before+1)
+		}
+	})
+
+	t.Run("otherPgError", func(t *testing.T) {
+		pgErr := fakePgError{code: "42P01"}
+		if err := classifyError(context.Background(), pgErr); err != pgErr {
+			t.Errorf("unexpected error: got %v want %v", err, pgErr)
+		}
+	})
+
+	t.Run("otherError", func(t *testing.T) {
+		otherErr := errors.New("connection refused")
+		if err := classifyError(context.Background(), otherErr); err != otherErr {
+			t.Errorf("unexpected error: got %v want %v", err, otherErr)
+		}
+	})
+
+	t.Run("contextDone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := classifyError(ctx, fakePgError{code: "57014"})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected error: got %v want %v", err, context.Canceled)
+		}
+	})
+}
@@ -0,0 +1,11 @@
+package todo
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var poolExhausted = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "todo_store_pool_exhausted_total",
+	Help: "Number of queries which timed out waiting for a connection from the pool.",
+})
@@ -2,7 +2,10 @@ package todo
 
 import (
 	"errors"
+	"time"
+	"unicode/utf8"
 
+	"github.com/go-pg/pg"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/net/context"
 
@@ -13,7 +16,42 @@ import (
 type TodoStore interface {
 	GetTodo(ctx context.Context, id int) (models.TodoItem, bool, error)
 	DeleteTodo(ctx context.Context, id int) (int, error)
-	PostTodo(ctx context.Context, todo models.TodoItem) (int, error)
+	PostTodo(ctx context.Context, todo models.TodoItem) (models.TodoItem, error)
+	PostTodoIfNotExists(ctx context.Context, todo models.TodoItem) (models.TodoItem, bool, error)
+	GetTodosSince(ctx context.Context, since time.Time, limit int) ([]models.TodoItem, error)
+	GetTodoMap(ctx context.Context, ids []int) (map[int]models.TodoItem, error)
+	GetTodoWithNeighbors(ctx context.Context, id int, sort string) (models.TodoWithNeighbors, bool, error)
+	IncrementAttempts(ctx context.Context, id int) (int, error)
+	TouchTodo(ctx context.Context, id int) (int, error)
+	RevokeShares(ctx context.Context, id int) (int, error)
+	CountDistinct(ctx context.Context, field string) (int, error)
+	Capabilities() models.Capabilities
+}
+
+// neighborSorts are the sort orders supported by GetTodoWithNeighbors mapped to their column, ties are broken by id
+var neighborSorts = map[string]string{
+	"id":         "id",
+	"created_on": "created_on",
+}
+
+// neighborsQuery selects the todo with the ids of the todos before and after it ordered by column ?0 then id, it's run
+// on the TodoItem model for ?TableName
+const neighborsQuery = `SELECT t.*,
+	(SELECT p.id FROM ?TableName AS p WHERE (p.?0, p.id) < (t.?0, t.id) ORDER BY p.?0 DESC, p.id DESC LIMIT 1) AS prev_id,
+	(SELECT n.id FROM ?TableName AS n WHERE (n.?0, n.id) > (t.?0, t.id) ORDER BY n.?0 ASC, n.id ASC LIMIT 1) AS next_id
+FROM ?TableName AS t WHERE t.id = ?1`
+
+// distinctFields are the fields CountDistinct supports mapped to their column, they have few distinct values
+var distinctFields = map[string]string{
+	"attempts": "attempts",
+}
+
+// highCardinalityFields are rejected by CountDistinct since nearly every todo has its own value
+var highCardinalityFields = map[string]bool{
+	"id":         true,
+	"todo":       true,
+	"created_on": true,
+	"updated_on": true,
 }
 
 type Store struct {
@@ -42,7 +80,7 @@ func (s *Store) GetTodo(ctx context.Context, id int) (models.TodoItem, bool, err
 			return models.TodoItem{}, false, nil
 		}
 		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to get todo from db")
-		return result, false, err
+		return result, false, classifyError(ctx, err)
 	}
 
 	log.Ctx(ctx).Debug().Caller().Msg("todo found from db")
@@ -60,31 +98,258 @@ func (s *Store) DeleteTodo(ctx context.Context, id int) (int, error) {
 		Delete()
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to delete todo from db")
-		return 0, err
+		return 0, classifyError(ctx, err)
 	}
 
 	log.Ctx(ctx).Debug().Caller().Msgf("todo deleted from db")
 	return result.RowsAffected(), nil
 }
 
-// PostTodo posts a TodoItem to the database
-func (s *Store) PostTodo(ctx context.Context, todo models.TodoItem) (int, error) {
+// PostTodo posts a TodoItem to the database and returns the inserted row in the same statement
+func (s *Store) PostTodo(ctx context.Context, todo models.TodoItem) (models.TodoItem, error) {
 	log.Ctx(ctx).Debug().Caller().Msg("insert db request for todo")
 
+	// guards the column size in case handler validation is bypassed
+	if utf8.RuneCountInString(todo.Todo) > models.TodoMaxLength {
+		return models.TodoItem{}, ErrTooLong
+	}
+
 	result, err := s.pgClient.GetConnection().
 		Model(&todo).
 		Context(ctx).
-		Returning("id").
+		Returning("*").
 		Insert(&todo)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to insert todo into db")
-		return 0, err
+		return models.TodoItem{}, classifyError(ctx, err)
 	}
 	if result.RowsAffected() == 0 {
 		iErr := errors.New("failed to insert record")
 		log.Ctx(ctx).Error().Err(iErr).Caller().Msg("failed to insert todo into db")
-		return 0, iErr
+		return models.TodoItem{}, iErr
+	}
+
+	return todo, nil
+}
+
+// PostTodoIfNotExists posts a TodoItem to the database unless a todo with the same text exists, returning false
+// without inserting when one does. Conditional creates of the same text are serialized with a transaction scoped
+// advisory lock so concurrent requests can't both insert, unconditional PostTodo calls don't take the lock.
+func (s *Store) PostTodoIfNotExists(ctx context.Context, todo models.TodoItem) (models.TodoItem, bool, error) {
+	log.Ctx(ctx).Debug().Caller().Msg("conditional insert db request for todo")
+
+	// guards the column size in case handler validation is bypassed
+	if utf8.RuneCountInString(todo.Todo) > models.TodoMaxLength {
+		return models.TodoItem{}, false, ErrTooLong
+	}
+
+	created := false
+	err := s.pgClient.GetConnection().RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext(?))", todo.Todo); err != nil {
+			return err
+		}
+
+		exists, err := tx.Model((*models.TodoItem)(nil)).
+			Context(ctx).
+			Where("todo = ?", todo.Todo).
+			Exists()
+		if err != nil || exists {
+			return err
+		}
+
+		if _, err := tx.Model(&todo).Context(ctx).Returning("*").Insert(&todo); err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to conditionally insert todo into db")
+		return models.TodoItem{}, false, classifyError(ctx, err)
+	}
+	if !created {
+		log.Ctx(ctx).Debug().Caller().Msg("todo already exists in db, not inserted")
+		return models.TodoItem{}, false, nil
+	}
+
+	return todo, true, nil
+}
+
+// GetTodosSince gets up to limit TodoItem's created or updated after since from the database, least recently updated
+// first
+func (s *Store) GetTodosSince(ctx context.Context, since time.Time, limit int) ([]models.TodoItem, error) {
+	log.Ctx(ctx).Debug().Caller().Msg("get db request for todos since")
+
+	var result []models.TodoItem
+	err := s.pgClient.GetConnection().
+		Model(&result).
+		Context(ctx).
+		Where("updated_on > ?", since).
+		Order("updated_on ASC", "id ASC").
+		Limit(limit).
+		Select()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to get todos since from db")
+		return nil, classifyError(ctx, err)
+	}
+
+	log.Ctx(ctx).Debug().Caller().Msgf("%d todos found since from db", len(result))
+	return result, nil
+}
+
+// GetTodoMap gets the TodoItem's with the given ids from the database keyed by id, missing ids are left out of the map
+func (s *Store) GetTodoMap(ctx context.Context, ids []int) (map[int]models.TodoItem, error) {
+	log.Ctx(ctx).Debug().Caller().Msg("get db request for todo map")
+
+	result := make(map[int]models.TodoItem, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var todos []models.TodoItem
+	err := s.pgClient.GetConnection().
+		Model(&todos).
+		Context(ctx).
+		Where("id = ANY(?)", pg.Array(ids)).
+		Select()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to get todo map from db")
+		return nil, classifyError(ctx, err)
+	}
+
+	for _, todo := range todos {
+		result[todo.ID] = todo
+	}
+
+	log.Ctx(ctx).Debug().Caller().Msgf("%d of %d todos found from db", len(result), len(ids))
+	return result, nil
+}
+
+// GetTodoWithNeighbors gets a TodoItem from the database with the ids of the previous and next todos in the sort
+// order, so a detail view can navigate without listing. Returns ErrInvalidSort for an unsupported sort
+func (s *Store) GetTodoWithNeighbors(ctx context.Context, id int, sort string) (models.TodoWithNeighbors, bool, error) {
+	log.Ctx(ctx).Debug().Caller().Msg("get db request for todo with neighbors")
+
+	column, ok := neighborSorts[sort]
+	if !ok {
+		return models.TodoWithNeighbors{}, false, ErrInvalidSort
+	}
+
+	var result models.TodoWithNeighbors
+	_, err := s.pgClient.GetConnection().
+		Model((*models.TodoItem)(nil)).
+		Context(ctx).
+		QueryOne(&result, neighborsQuery, pg.F(column), id)
+	if err == pg.ErrNoRows {
+		return models.TodoWithNeighbors{}, false, nil
+	}
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to get todo with neighbors from db")
+		return models.TodoWithNeighbors{}, false, classifyError(ctx, err)
+	}
+
+	log.Ctx(ctx).Debug().Caller().Msg("todo with neighbors found from db")
+	return result, true, nil
+}
+
+// IncrementAttempts atomically increments the attempts counter of a TodoItem and returns the new count, concurrent
+// callers each get a distinct count. Returns ErrNotFound when the todo doesn't exist
+func (s *Store) IncrementAttempts(ctx context.Context, id int) (int, error) {
+	log.Ctx(ctx).Debug().Caller().Msg("increment attempts db request for todo")
+
+	var attempts int
+	result, err := s.pgClient.GetConnection().
+		Model((*models.TodoItem)(nil)).
+		Context(ctx).
+		Set("attempts = attempts + 1").
+		Where("id = ?", id).
+		Returning("attempts").
+		Update(pg.Scan(&attempts))
+	if err == pg.ErrNoRows || (err == nil && result.RowsAffected() == 0) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to increment todo attempts in db")
+		return 0, classifyError(ctx, err)
+	}
+
+	log.Ctx(ctx).Debug().Caller().Msgf("todo attempts incremented to %d in db", attempts)
+	return attempts, nil
+}
+
+// TouchTodo sets the updated_on of a TodoItem to the database's now, leaving every other field unchanged, and returns
+// the number of rows updated
+func (s *Store) TouchTodo(ctx context.Context, id int) (int, error) {
+	log.Ctx(ctx).Debug().Caller().Msg("touch db request for todo")
+
+	result, err := s.pgClient.GetConnection().
+		Model((*models.TodoItem)(nil)).
+		Context(ctx).
+		Set("updated_on = now()").
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to touch todo in db")
+		return 0, classifyError(ctx, err)
+	}
+
+	log.Ctx(ctx).Debug().Caller().Msgf("%d todos touched in db", result.RowsAffected())
+	return result.RowsAffected(), nil
+}
+
+// RevokeShares bumps the share_version of a TodoItem so the share links minted for it no longer resolve, and returns
+// the number of rows updated
+func (s *Store) RevokeShares(ctx context.Context, id int) (int, error) {
+	log.Ctx(ctx).Debug().Caller().Msg("revoke shares db request for todo")
+
+	result, err := s.pgClient.GetConnection().
+		Model((*models.TodoItem)(nil)).
+		Context(ctx).
+		Set("share_version = share_version + 1").
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to revoke todo shares in db")
+		return 0, classifyError(ctx, err)
+	}
+
+	log.Ctx(ctx).Debug().Caller().Msgf("%d todos with shares revoked in db", result.RowsAffected())
+	return result.RowsAffected(), nil
+}
+
+// CountDistinct counts the distinct values of a field across all todos, e.g. to decide whether a filter dropdown is
+// worth showing. Fields outside distinctFields return ErrInvalidField, or ErrHighCardinalityField for a known field
+// which is nearly unique.
+func (s *Store) CountDistinct(ctx context.Context, field string) (int, error) {
+	log.Ctx(ctx).Debug().Caller().Str("field", field).Msg("count distinct db request for todos")
+
+	column, ok := distinctFields[field]
+	if !ok {
+		if highCardinalityFields[field] {
+			return 0, ErrHighCardinalityField
+		}
+		return 0, ErrInvalidField
 	}
 
-	return todo.ID, err
+	var count int
+	err := s.pgClient.GetConnection().
+		Model((*models.TodoItem)(nil)).
+		Context(ctx).
+		ColumnExpr("count(DISTINCT ?)", pg.F(column)).
+		Select(pg.Scan(&count))
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to count distinct todo values in db")
+		return 0, classifyError(ctx, err)
+	}
+
+	log.Ctx(ctx).Debug().Caller().Msgf("%d distinct %s values in db", count, field)
+	return count, nil
+}
+
+// Capabilities reports the optional features of the Postgres store
+func (s *Store) Capabilities() models.Capabilities {
+	return models.Capabilities{
+		Backend:      "postgres",
+		Transactions: true,
+	}
 }
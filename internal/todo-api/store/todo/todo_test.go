@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -115,3 +117,558 @@ func TestGetTodo_ValidEmptyResponse(t *testing.T) {
 	dbMock.AssertNumberOfCalls(t, "GetConnection", 1)
 	dbMock.AssertExpectations(t)
 }
+
+func TestPostTodo_ReturnsInsertedRow(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+
+	dbMock.On("GetConnection").Return(db)
+
+	createdOn := time.Now().UTC().Truncate(time.Second)
+	inserted, err := todoStore.PostTodo(context.Background(), models.TodoItem{
+		Todo:      "test",
+		CreatedOn: createdOn,
+	})
+	unexpected(t, err)
+
+	if inserted.ID <= 0 {
+		t.Errorf("unexpected id: %v", inserted.ID)
+	}
+	if inserted.Todo != "test" || !inserted.CreatedOn.Equal(createdOn) {
+		t.Errorf("unexpected result: %v", inserted)
+	}
+
+	dbMock.AssertNumberOfCalls(t, "GetConnection", 1)
+	dbMock.AssertExpectations(t)
+}
+
+func TestPostTodo_DatabaseCreatedOn(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+
+	dbMock.On("GetConnection").Return(db)
+
+	inserted, err := todoStore.PostTodo(context.Background(), models.TodoItem{Todo: "test"})
+	unexpected(t, err)
+
+	if inserted.CreatedOn.IsZero() {
+		t.Errorf("expected created_on to be assigned by the database: %v", inserted)
+	}
+}
+
+func TestGetTodosSince(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+
+	dbMock.On("GetConnection").Return(db)
+
+	since := time.Now().UTC().Truncate(time.Second)
+	var ids []int
+	for i, createdOn := range []time.Time{since.Add(-time.Hour), since.Add(time.Minute), since.Add(time.Hour)} {
+		inserted, err := todoStore.PostTodo(context.Background(), models.TodoItem{
+			Todo:      fmt.Sprint("test ", i),
+			CreatedOn: createdOn,
+			UpdatedOn: createdOn,
+		})
+		unexpected(t, err)
+		ids = append(ids, inserted.ID)
+	}
+
+	changes, err := todoStore.GetTodosSince(context.Background(), since, 10)
+	unexpected(t, err)
+
+	if len(changes) != 2 {
+		t.Errorf("unexpected result count: got %v want %v", len(changes), 2)
+		t.FailNow()
+	}
+	if changes[0].Todo != "test 1" || changes[1].Todo != "test 2" {
+		t.Errorf("unexpected result order: %v", changes)
+	}
+
+	// touching the todo created before since makes it a change, updated before the others
+	_, err = todoStore.TouchTodo(context.Background(), ids[0])
+	unexpected(t, err)
+
+	changes, err = todoStore.GetTodosSince(context.Background(), since, 10)
+	unexpected(t, err)
+
+	if len(changes) != 3 || changes[0].Todo != "test 0" {
+		t.Errorf("unexpected changes after touch: %v", changes)
+	}
+
+	dbMock.AssertExpectations(t)
+}
+
+func TestGetTodoMap(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+
+	dbMock.On("GetConnection").Return(db)
+
+	first, err := todoStore.PostTodo(context.Background(), models.TodoItem{Todo: "first", CreatedOn: time.Now()})
+	unexpected(t, err)
+	second, err := todoStore.PostTodo(context.Background(), models.TodoItem{Todo: "second", CreatedOn: time.Now()})
+	unexpected(t, err)
+
+	missingID := second.ID + 100
+	result, err := todoStore.GetTodoMap(context.Background(), []int{first.ID, missingID, second.ID})
+	unexpected(t, err)
+
+	if len(result) != 2 {
+		t.Errorf("unexpected result size: got %v want %v", len(result), 2)
+	}
+	if result[first.ID].Todo != "first" || result[second.ID].Todo != "second" {
+		t.Errorf("unexpected result: %v", result)
+	}
+	if _, found := result[missingID]; found {
+		t.Errorf("unexpected todo for missing id %v", missingID)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	store := Store{}
+
+	expected := models.Capabilities{Backend: "postgres", Transactions: true}
+	if result := store.Capabilities(); result != expected {
+		t.Errorf("unexpected capabilities: got %v want %v", result, expected)
+	}
+}
+
+func TestPostTodo_TooLong(t *testing.T) {
+	store := Store{}
+
+	_, err := store.PostTodo(context.Background(), models.TodoItem{Todo: strings.Repeat("a", models.TodoMaxLength+1)})
+	if !errors.Is(err, ErrTooLong) {
+		t.Errorf("unexpected error: got %v want %v", err, ErrTooLong)
+	}
+}
+
+func TestGetTodo_PoolExhausted(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	// a single connection which is held by a slow query
+	tinyDb := pg.Connect(&pg.Options{
+		User:        db.Options().User,
+		Addr:        db.Options().Addr,
+		Password:    db.Options().Password,
+		Database:    db.Options().Database,
+		PoolSize:    1,
+		PoolTimeout: 100 * time.Millisecond,
+	})
+	defer tinyDb.Close()
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+	dbMock.On("GetConnection").Return(tinyDb)
+
+	slowQuery := make(chan error)
+	go func() {
+		_, err := tinyDb.Exec("SELECT pg_sleep(1)")
+		slowQuery <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	_, _, err := todoStore.GetTodo(context.Background(), 1)
+	if !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("unexpected error: got %v want %v", err, ErrPoolExhausted)
+	}
+	unexpected(t, <-slowQuery)
+}
+
+func TestIncrementAttempts(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+
+	dbMock.On("GetConnection").Return(db)
+
+	inserted, err := todoStore.PostTodo(context.Background(), models.TodoItem{Todo: "test", CreatedOn: time.Now()})
+	unexpected(t, err)
+
+	const workers = 50
+	var wg sync.WaitGroup
+	counts := make(chan int, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempts, err := todoStore.IncrementAttempts(context.Background(), inserted.ID)
+			if err != nil {
+				t.Errorf("unexpected error: %+v", err)
+				return
+			}
+			counts <- attempts
+		}()
+	}
+	wg.Wait()
+	close(counts)
+
+	// every increment returns a distinct count, a lost update would repeat one
+	seen := make(map[int]bool, workers)
+	for attempts := range counts {
+		if seen[attempts] || attempts < 1 || attempts > workers {
+			t.Errorf("unexpected attempts count: %v", attempts)
+		}
+		seen[attempts] = true
+	}
+
+	result, found, err := todoStore.GetTodo(context.Background(), inserted.ID)
+	unexpected(t, err)
+	if !found || result.Attempts != workers {
+		t.Errorf("unexpected attempts: got %v want %v", result.Attempts, workers)
+	}
+
+	_, err = todoStore.IncrementAttempts(context.Background(), inserted.ID+1)
+	if err != ErrNotFound {
+		t.Errorf("unexpected error for missing todo: got %v want %v", err, ErrNotFound)
+	}
+}
+
+// cancellationDeadline bounds how long a store call may take to return once its ctx is done
+const cancellationDeadline = time.Second
+
+// lockTodoTable holds an exclusive lock on the todo table so store queries block until it's released
+func lockTodoTable(t *testing.T, db *pg.DB) (release func()) {
+	tx, err := db.Begin()
+	unexpected(t, errors.Wrap(err, "failed to begin tx"))
+
+	_, err = tx.Model((*models.TodoItem)(nil)).Exec("LOCK TABLE ?TableName IN ACCESS EXCLUSIVE MODE")
+	unexpected(t, errors.Wrap(err, "failed to lock todo table"))
+
+	return func() {
+		_ = tx.Rollback()
+	}
+}
+
+func TestStore_QueryCancellation(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+	dbMock.On("GetConnection").Return(db)
+
+	calls := map[string]func(ctx context.Context) error{
+		"GetTodo": func(ctx context.Context) error {
+			_, _, err := todoStore.GetTodo(ctx, 1)
+			return err
+		},
+		"DeleteTodo": func(ctx context.Context) error {
+			_, err := todoStore.DeleteTodo(ctx, 1)
+			return err
+		},
+		"PostTodo": func(ctx context.Context) error {
+			_, err := todoStore.PostTodo(ctx, models.TodoItem{Todo: "test", CreatedOn: time.Now()})
+			return err
+		},
+		"GetTodosSince": func(ctx context.Context) error {
+			_, err := todoStore.GetTodosSince(ctx, time.Time{}, 10)
+			return err
+		},
+		"GetTodoMap": func(ctx context.Context) error {
+			_, err := todoStore.GetTodoMap(ctx, []int{1})
+			return err
+		},
+		"IncrementAttempts": func(ctx context.Context) error {
+			_, err := todoStore.IncrementAttempts(ctx, 1)
+			return err
+		},
+	}
+
+	for name, call := range calls {
+		release := lockTodoTable(t, db)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		start := time.Now()
+		err := call(ctx)
+		elapsed := time.Since(start)
+		release()
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected error for %v: got %v want %v", name, err, context.Canceled)
+		}
+		if elapsed > cancellationDeadline {
+			t.Errorf("%v took %v to return after cancellation", name, elapsed)
+		}
+	}
+}
+
+func TestPostTodoIfNotExists(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+	dbMock.On("GetConnection").Return(db)
+
+	const workers = 10
+	var wg sync.WaitGroup
+	results := make(chan bool, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, created, err := todoStore.PostTodoIfNotExists(context.Background(), models.TodoItem{Todo: "test", CreatedOn: time.Now()})
+			if err != nil {
+				t.Errorf("unexpected error: %+v", err)
+				return
+			}
+			results <- created
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	created := 0
+	for result := range results {
+		if result {
+			created++
+		}
+	}
+	if created != 1 {
+		t.Errorf("unexpected number of creates: got %v want %v", created, 1)
+	}
+}
+
+func TestTouchTodo(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+	dbMock.On("GetConnection").Return(db)
+
+	createdOn := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	inserted, err := todoStore.PostTodo(context.Background(), models.TodoItem{
+		Todo:      "test",
+		CreatedOn: createdOn,
+		UpdatedOn: createdOn,
+	})
+	unexpected(t, err)
+
+	count, err := todoStore.TouchTodo(context.Background(), inserted.ID)
+	unexpected(t, err)
+	if count != 1 {
+		t.Errorf("unexpected touched count: got %v want %v", count, 1)
+	}
+
+	touched, found, err := todoStore.GetTodo(context.Background(), inserted.ID)
+	unexpected(t, err)
+	if !found || !touched.UpdatedOn.After(inserted.UpdatedOn) {
+		t.Errorf("expected updated_on to move forward: got %v was %v", touched.UpdatedOn, inserted.UpdatedOn)
+	}
+	touched.UpdatedOn = inserted.UpdatedOn
+	if touched.ID != inserted.ID || touched.Todo != inserted.Todo || !touched.CreatedOn.Equal(inserted.CreatedOn) ||
+		touched.Attempts != inserted.Attempts {
+		t.Errorf("unexpected fields changed by touch: got %v was %v", touched, inserted)
+	}
+
+	count, err = todoStore.TouchTodo(context.Background(), inserted.ID+1)
+	unexpected(t, err)
+	if count != 0 {
+		t.Errorf("unexpected touched count for missing todo: got %v want %v", count, 0)
+	}
+}
+
+func TestRevokeShares(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+	dbMock.On("GetConnection").Return(db)
+
+	inserted, err := todoStore.PostTodo(context.Background(), models.TodoItem{Todo: "test"})
+	unexpected(t, err)
+
+	count, err := todoStore.RevokeShares(context.Background(), inserted.ID)
+	unexpected(t, err)
+	if count != 1 {
+		t.Errorf("unexpected revoked count: got %v want %v", count, 1)
+	}
+
+	revoked, found, err := todoStore.GetTodo(context.Background(), inserted.ID)
+	unexpected(t, err)
+	if !found || revoked.ShareVersion != inserted.ShareVersion+1 {
+		t.Errorf("unexpected share version: got %v want %v", revoked.ShareVersion, inserted.ShareVersion+1)
+	}
+
+	count, err = todoStore.RevokeShares(context.Background(), inserted.ID+1)
+	unexpected(t, err)
+	if count != 0 {
+		t.Errorf("unexpected revoked count for missing todo: got %v want %v", count, 0)
+	}
+}
+
+func TestGetTodoWithNeighbors(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+	dbMock.On("GetConnection").Return(db)
+
+	// created_on runs opposite to id so the two sorts give different neighbors
+	start := time.Now().UTC().Truncate(time.Second)
+	var ids []int
+	for i := 0; i < 3; i++ {
+		inserted, err := todoStore.PostTodo(context.Background(), models.TodoItem{
+			Todo:      fmt.Sprint("test ", i),
+			CreatedOn: start.Add(-time.Duration(i) * time.Minute),
+		})
+		unexpected(t, err)
+		ids = append(ids, inserted.ID)
+	}
+
+	idOf := func(id *int) interface{} {
+		if id == nil {
+			return nil
+		}
+		return *id
+	}
+	cases := []struct {
+		name string
+		sort string
+		id   int
+		prev interface{}
+		next interface{}
+	}{
+		{"firstByID", "id", ids[0], nil, ids[1]},
+		{"middleByID", "id", ids[1], ids[0], ids[2]},
+		{"lastByID", "id", ids[2], ids[1], nil},
+		{"firstByCreatedOn", "created_on", ids[2], nil, ids[1]},
+		{"lastByCreatedOn", "created_on", ids[0], ids[1], nil},
+	}
+	for _, c := range cases {
+		result, found, err := todoStore.GetTodoWithNeighbors(context.Background(), c.id, c.sort)
+		unexpected(t, err)
+		if !found || result.ID != c.id {
+			t.Errorf("unexpected todo for %v: %v", c.name, result)
+		}
+		if idOf(result.PrevID) != c.prev || idOf(result.NextID) != c.next {
+			t.Errorf("unexpected neighbors for %v: got %v, %v want %v, %v", c.name, idOf(result.PrevID),
+				idOf(result.NextID), c.prev, c.next)
+		}
+	}
+
+	_, _, err := todoStore.GetTodoWithNeighbors(context.Background(), ids[0], "todo")
+	if err != ErrInvalidSort {
+		t.Errorf("unexpected error: got %v want %v", err, ErrInvalidSort)
+	}
+}
+
+func TestCountDistinct(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+	dbMock.On("GetConnection").Return(db)
+
+	for i := 0; i < 3; i++ {
+		inserted, err := todoStore.PostTodo(context.Background(), models.TodoItem{Todo: fmt.Sprint("test", i)})
+		unexpected(t, err)
+		if i > 0 {
+			_, err = todoStore.IncrementAttempts(context.Background(), inserted.ID)
+			unexpected(t, err)
+		}
+	}
+
+	count, err := todoStore.CountDistinct(context.Background(), "attempts")
+	unexpected(t, err)
+	if count != 2 {
+		t.Errorf("unexpected distinct attempts: got %v want %v", count, 2)
+	}
+}
+
+func TestCountDistinct_RejectedFields(t *testing.T) {
+	store := Store{}
+
+	cases := map[string]error{
+		"id":       ErrHighCardinalityField,
+		"todo":     ErrHighCardinalityField,
+		"priority": ErrInvalidField,
+	}
+	for field, expected := range cases {
+		if _, err := store.CountDistinct(context.Background(), field); !errors.Is(err, expected) {
+			t.Errorf("unexpected error for %v: got %v want %v", field, err, expected)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"context"
+)
+
+// contextKey namespaces the values this service stores in a context. Every value gets its own unexported key so it
+// can't collide with a value set by a middleware or library under the same name, e.g. a plain "id" string key. Values
+// are only set and read through helpers like WithTodoID and TodoID, never with context.WithValue directly.
+type contextKey struct {
+	name string
+}
+
+var todoIDKey = &contextKey{"todo_id"}
+
+// WithTodoID returns a derived ctx carrying the todo id
+func WithTodoID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, todoIDKey, id)
+}
+
+// TodoID returns the todo id carried by ctx
+func TodoID(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(todoIDKey).(int)
+	return id, ok
+}
+
+var requestIDKey = &contextKey{"request_id"}
+
+// WithRequestID returns a derived ctx carrying the request id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request id carried by ctx
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok
+}
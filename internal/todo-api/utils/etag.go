@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"strings"
+)
+
+// ETag is an entity tag validator, Value is the opaque tag without quotes
+type ETag struct {
+	Value string
+	Weak  bool
+}
+
+// String formats the ETag for the ETag header
+func (e ETag) String() string {
+	if e.Weak {
+		return `W/"` + e.Value + `"`
+	}
+	return `"` + e.Value + `"`
+}
+
+// ParseETags parses an If-Match or If-None-Match header value, wildcard is true when the whole value is `*`. Parsing
+// stops at the first malformed entry, including a `*` which isn't alone.
+func ParseETags(header string) (tags []ETag, wildcard bool) {
+	if strings.Trim(header, " \t") == "*" {
+		return nil, true
+	}
+
+	s := header
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return tags, false
+		}
+
+		weak := strings.HasPrefix(s, "W/")
+		if weak {
+			s = s[2:]
+		}
+		if len(s) < 2 || s[0] != '"' {
+			return tags, false
+		}
+		end := strings.IndexByte(s[1:], '"')
+		if end < 0 {
+			return tags, false
+		}
+
+		tags = append(tags, ETag{Value: s[1 : end+1], Weak: weak})
+		s = s[end+2:]
+	}
+}
+
+// MatchIfNoneMatch reports whether the If-None-Match header matches current using weak comparison, a match means the
+// client's copy is fresh
+func MatchIfNoneMatch(header string, current ETag) bool {
+	tags, wildcard := ParseETags(header)
+	if wildcard {
+		return true
+	}
+	for _, tag := range tags {
+		if tag.Value == current.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchIfMatch reports whether the If-Match header matches current using strong comparison, weak validators never
+// match
+func MatchIfMatch(header string, current ETag) bool {
+	tags, wildcard := ParseETags(header)
+	if wildcard {
+		return true
+	}
+	if current.Weak {
+		return false
+	}
+	for _, tag := range tags {
+		if !tag.Weak && tag.Value == current.Value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseETags(t *testing.T) {
+	cases := []struct {
+		name             string
+		header           string
+		expected         []ETag
+		expectedWildcard bool
+	}{
+		{"empty", "", nil, false},
+		{"wildcard", "*", nil, true},
+		{"paddedWildcard", " * ", nil, true},
+		{"malformedWildcard", "*foo", nil, false},
+		{"wildcardInList", `"a", *`, []ETag{{Value: "a"}}, false},
+		{"strong", `"abc"`, []ETag{{Value: "abc"}}, false},
+		{"weak", `W/"abc"`, []ETag{{Value: "abc", Weak: true}}, false},
+		{"multiple", `"a", W/"b" ,"c,d"`, []ETag{{Value: "a"}, {Value: "b", Weak: true}, {Value: "c,d"}}, false},
+		{"malformed", `"a", b`, []ETag{{Value: "a"}}, false},
+		{"unterminated", `"a`, nil, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tags, wildcard := ParseETags(c.header)
+			if !reflect.DeepEqual(tags, c.expected) {
+				t.Errorf("unexpected tags: got %v want %v", tags, c.expected)
+			}
+			if wildcard != c.expectedWildcard {
+				t.Errorf("unexpected wildcard: got %v want %v", wildcard, c.expectedWildcard)
+			}
+		})
+	}
+}
+
+func TestETagMatching(t *testing.T) {
+	strong := ETag{Value: "abc"}
+	weak := ETag{Value: "abc", Weak: true}
+
+	cases := []struct {
+		name              string
+		header            string
+		current           ETag
+		expectedNoneMatch bool
+		expectedMatch     bool
+	}{
+		{"wildcard", "*", strong, true, true},
+		{"strongBoth", `"abc"`, strong, true, true},
+		{"weakHeader", `W/"abc"`, strong, true, false},
+		{"weakCurrent", `"abc"`, weak, true, false},
+		{"weakBoth", `W/"abc"`, weak, true, false},
+		{"multipleValues", `"x", "abc"`, strong, true, true},
+		{"noMatch", `"x", W/"y"`, strong, false, false},
+		{"empty", "", strong, false, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if result := MatchIfNoneMatch(c.header, c.current); result != c.expectedNoneMatch {
+				t.Errorf("unexpected If-None-Match result: got %v want %v", result, c.expectedNoneMatch)
+			}
+			if result := MatchIfMatch(c.header, c.current); result != c.expectedMatch {
+				t.Errorf("unexpected If-Match result: got %v want %v", result, c.expectedMatch)
+			}
+		})
+	}
+
+	t.Run("string", func(t *testing.T) {
+		if strong.String() != `"abc"` || weak.String() != `W/"abc"` {
+			t.Errorf("unexpected format: got %v and %v", strong.String(), weak.String())
+		}
+	})
+}
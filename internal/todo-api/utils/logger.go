@@ -4,17 +4,19 @@ import (
 	"context"
 
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/hlog"
 )
 
+// GetSubLoggerCtx returns a derived ctx carrying a sub-logger with the request fields. The logger already in ctx, e.g.
+// from the logging middleware or a previous call, is extended so upstream fields like the request id are kept and
+// every other ctx value is left untouched. Without one the given logger is used.
 func GetSubLoggerCtx(logger zerolog.Logger, ctx context.Context) context.Context {
 	subLogger := logger
-	reqId, ok := hlog.IDFromCtx(ctx)
-	if ok {
-		subLogger = subLogger.With().Str("reqID", reqId.String()).Logger()
+	if ctxLogger := zerolog.Ctx(ctx); ctxLogger.GetLevel() != zerolog.Disabled {
+		subLogger = *ctxLogger
+	} else if reqID, ok := RequestID(ctx); ok {
+		subLogger = subLogger.With().Str("req_id", reqID).Logger()
 	}
-	id, ok := ctx.Value("id").(int)
-	if ok {
+	if id, ok := TodoID(ctx); ok {
 		subLogger = subLogger.With().Int("id", id).Logger()
 	}
 	return subLogger.WithContext(ctx)
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type upstreamKey struct{}
+
+func TestGetSubLoggerCtx(t *testing.T) {
+	t.Run("keepsUpstreamLoggerAndValues", func(t *testing.T) {
+		var buf bytes.Buffer
+		upstreamLogger := zerolog.New(&buf).With().Str("req_id", "upstream-id").Logger()
+		ctx := upstreamLogger.WithContext(context.WithValue(context.Background(), upstreamKey{}, "user"))
+
+		logCtx := GetSubLoggerCtx(zerolog.New(&buf), WithTodoID(ctx, 1))
+		log.Ctx(logCtx).Info().Msg("store call")
+
+		logged := buf.String()
+		if !strings.Contains(logged, `"req_id":"upstream-id"`) || !strings.Contains(logged, `"id":1`) {
+			t.Errorf("expected upstream fields in log: %v", logged)
+		}
+		if logCtx.Value(upstreamKey{}) != "user" {
+			t.Errorf("upstream ctx value was lost: %v", logCtx.Value(upstreamKey{}))
+		}
+	})
+
+	t.Run("upstreamIDNotShadowed", func(t *testing.T) {
+		var buf bytes.Buffer
+		// an upstream middleware using the same name as a plain string key
+		ctx := context.WithValue(context.Background(), "id", "upstream") // nolint:staticcheck
+
+		logCtx := GetSubLoggerCtx(zerolog.New(&buf), WithTodoID(ctx, 1))
+		log.Ctx(logCtx).Info().Msg("store call")
+
+		if logCtx.Value("id") != "upstream" {
+			t.Errorf("upstream ctx value was shadowed: %v", logCtx.Value("id"))
+		}
+		if id, ok := TodoID(logCtx); !ok || id != 1 {
+			t.Errorf("unexpected todo id: got %v want %v", id, 1)
+		}
+		if !strings.Contains(buf.String(), `"id":1`) {
+			t.Errorf("expected todo id in log: %v", buf.String())
+		}
+	})
+
+	t.Run("fallbackLogger", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logCtx := GetSubLoggerCtx(zerolog.New(&buf), context.Background())
+		log.Ctx(logCtx).Info().Msg("store call")
+
+		if !strings.Contains(buf.String(), "store call") {
+			t.Errorf("expected log from the given logger: %v", buf.String())
+		}
+	})
+}
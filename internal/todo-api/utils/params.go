@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/pkg/errors"
+)
+
+// URLParamInt extracts the named chi URL parameter as an integer, the error message is safe to return to the client
+func URLParamInt(r *http.Request, name string) (int, error) {
+	value := chi.URLParam(r, name)
+	err := validation.Validate(value,
+		validation.Required.Error(fmt.Sprint(name, " is required")),
+		is.Int.Error(fmt.Sprint(name, " must be an integer")),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.New(fmt.Sprint(name, " is out of range"))
+	}
+
+	return result, nil
+}
+
+// ParseDuration parses a named duration like `10s` which has to be between min and max inclusive, rejecting out of
+// range values instead of silently capping them. The error message is safe to return to the client
+func ParseDuration(name, value string, min, max time.Duration) (time.Duration, error) {
+	result, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.New(fmt.Sprint(name, " must be a duration like 10s"))
+	}
+	if result < min {
+		return 0, errors.New(fmt.Sprint(name, " must be at least ", min))
+	}
+	if result > max {
+		return 0, errors.New(fmt.Sprint(name, " must be at most ", max))
+	}
+
+	return result, nil
+}
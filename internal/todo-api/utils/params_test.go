@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+func newParamRequest(t *testing.T, name, value string) *http.Request {
+	req, err := http.NewRequest("GET", "/todo/"+value, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rCtx := chi.NewRouteContext()
+	rCtx.URLParams.Add(name, value)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+}
+
+func TestURLParamInt(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		id, err := URLParamInt(newParamRequest(t, "id", "42"), "id")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			t.FailNow()
+		}
+		if id != 42 {
+			t.Errorf("unexpected result: got %v want %v", id, 42)
+		}
+	})
+
+	cases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"missing", "", "id is required"},
+		{"notInteger", "bad", "id must be an integer"},
+		{"outOfRange", "99999999999999999999", "id is out of range"},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			_, err := URLParamInt(newParamRequest(t, "id", c.value), "id")
+			if err == nil {
+				t.Error("expected error")
+				t.FailNow()
+			}
+			if err.Error() != c.expected {
+				t.Errorf("unexpected error: got %v want %v", err.Error(), c.expected)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		wait, err := ParseDuration("wait", "1m30s", 0, time.Hour)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			t.FailNow()
+		}
+		if wait != 90*time.Second {
+			t.Errorf("unexpected result: got %v want %v", wait, 90*time.Second)
+		}
+	})
+
+	cases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"tooSmall", "-1s", "wait must be at least 0s"},
+		{"tooLarge", "1000000h", "wait must be at most 1h0m0s"},
+		{"overflow", "99999999999999999999h", "wait must be a duration like 10s"},
+		{"malformed", "10", "wait must be a duration like 10s"},
+		{"empty", "", "wait must be a duration like 10s"},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ParseDuration("wait", c.value, 0, time.Hour)
+			if err == nil {
+				t.Error("expected error")
+				t.FailNow()
+			}
+			if err.Error() != c.expected {
+				t.Errorf("unexpected error: got %v want %v", err.Error(), c.expected)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ package mocks
 
 import (
 	context "context"
+	time "time"
 
 	models "github.com/alexsniffin/go-api-starter/internal/todo-api/models"
 	mock "github.com/stretchr/testify/mock"
@@ -14,6 +15,41 @@ type TodoStore struct {
 	mock.Mock
 }
 
+// Capabilities provides a mock function with given fields:
+func (_m *TodoStore) Capabilities() models.Capabilities {
+	ret := _m.Called()
+
+	var r0 models.Capabilities
+	if rf, ok := ret.Get(0).(func() models.Capabilities); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(models.Capabilities)
+	}
+
+	return r0
+}
+
+// CountDistinct provides a mock function with given fields: ctx, field
+func (_m *TodoStore) CountDistinct(ctx context.Context, field string) (int, error) {
+	ret := _m.Called(ctx, field)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, field)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, field)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteTodo provides a mock function with given fields: ctx, id
 func (_m *TodoStore) DeleteTodo(ctx context.Context, id int) (int, error) {
 	ret := _m.Called(ctx, id)
@@ -63,15 +99,110 @@ func (_m *TodoStore) GetTodo(ctx context.Context, id int) (models.TodoItem, bool
 	return r0, r1, r2
 }
 
+// GetTodoMap provides a mock function with given fields: ctx, ids
+func (_m *TodoStore) GetTodoMap(ctx context.Context, ids []int) (map[int]models.TodoItem, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 map[int]models.TodoItem
+	if rf, ok := ret.Get(0).(func(context.Context, []int) map[int]models.TodoItem); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int]models.TodoItem)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []int) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTodoWithNeighbors provides a mock function with given fields: ctx, id, sort
+func (_m *TodoStore) GetTodoWithNeighbors(ctx context.Context, id int, sort string) (models.TodoWithNeighbors, bool, error) {
+	ret := _m.Called(ctx, id, sort)
+
+	var r0 models.TodoWithNeighbors
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) models.TodoWithNeighbors); ok {
+		r0 = rf(ctx, id, sort)
+	} else {
+		r0 = ret.Get(0).(models.TodoWithNeighbors)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) bool); ok {
+		r1 = rf(ctx, id, sort)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int, string) error); ok {
+		r2 = rf(ctx, id, sort)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetTodosSince provides a mock function with given fields: ctx, since, limit
+func (_m *TodoStore) GetTodosSince(ctx context.Context, since time.Time, limit int) ([]models.TodoItem, error) {
+	ret := _m.Called(ctx, since, limit)
+
+	var r0 []models.TodoItem
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) []models.TodoItem); ok {
+		r0 = rf(ctx, since, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TodoItem)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, since, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IncrementAttempts provides a mock function with given fields: ctx, id
+func (_m *TodoStore) IncrementAttempts(ctx context.Context, id int) (int, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PostTodo provides a mock function with given fields: ctx, _a1
-func (_m *TodoStore) PostTodo(ctx context.Context, _a1 models.TodoItem) (int, error) {
+func (_m *TodoStore) PostTodo(ctx context.Context, _a1 models.TodoItem) (models.TodoItem, error) {
 	ret := _m.Called(ctx, _a1)
 
-	var r0 int
-	if rf, ok := ret.Get(0).(func(context.Context, models.TodoItem) int); ok {
+	var r0 models.TodoItem
+	if rf, ok := ret.Get(0).(func(context.Context, models.TodoItem) models.TodoItem); ok {
 		r0 = rf(ctx, _a1)
 	} else {
-		r0 = ret.Get(0).(int)
+		r0 = ret.Get(0).(models.TodoItem)
 	}
 
 	var r1 error
@@ -83,3 +214,73 @@ func (_m *TodoStore) PostTodo(ctx context.Context, _a1 models.TodoItem) (int, er
 
 	return r0, r1
 }
+
+// PostTodoIfNotExists provides a mock function with given fields: ctx, _a1
+func (_m *TodoStore) PostTodoIfNotExists(ctx context.Context, _a1 models.TodoItem) (models.TodoItem, bool, error) {
+	ret := _m.Called(ctx, _a1)
+
+	var r0 models.TodoItem
+	if rf, ok := ret.Get(0).(func(context.Context, models.TodoItem) models.TodoItem); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		r0 = ret.Get(0).(models.TodoItem)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(context.Context, models.TodoItem) bool); ok {
+		r1 = rf(ctx, _a1)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, models.TodoItem) error); ok {
+		r2 = rf(ctx, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RevokeShares provides a mock function with given fields: ctx, id
+func (_m *TodoStore) RevokeShares(ctx context.Context, id int) (int, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TouchTodo provides a mock function with given fields: ctx, id
+func (_m *TodoStore) TouchTodo(ctx context.Context, id int) (int, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -1,11 +1,14 @@
 package config
 
 import (
+	"reflect"
 	"strings"
 
 	"github.com/spf13/viper"
 )
 
+const redactedValue = "*****"
+
 // Creates a config model with viper
 func NewConfig(fileName, prefix string, cfg interface{}) error {
 	v := viper.New()
@@ -29,3 +32,38 @@ func NewConfig(fileName, prefix string, cfg interface{}) error {
 	}
 	return nil
 }
+
+// Redact returns a copy of the config model with every non-empty string field tagged `redact:"true"` masked, so the
+// resolved config can be logged safely
+func Redact(cfg interface{}) interface{} {
+	v := reflect.ValueOf(cfg)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	redacted := reflect.New(v.Type()).Elem()
+	redacted.Set(v)
+	redactFields(redacted)
+
+	return redacted.Interface()
+}
+
+func redactFields(v reflect.Value) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		if v.Type().Field(i).Tag.Get("redact") == "true" && field.Kind() == reflect.String {
+			if field.String() != "" {
+				field.SetString(redactedValue)
+			}
+			continue
+		}
+		redactFields(field)
+	}
+}
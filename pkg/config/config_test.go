@@ -0,0 +1,60 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+type testDatabaseConfig struct {
+	User     string
+	Password string `redact:"true"`
+}
+
+type testConfig struct {
+	Environment string
+	APIKey      string `redact:"true"`
+	Database    testDatabaseConfig
+}
+
+func TestRedact(t *testing.T) {
+	t.Run("masksSensitiveFields", func(t *testing.T) {
+		cfg := testConfig{
+			Environment: "localhost",
+			APIKey:      "secret-key",
+			Database: testDatabaseConfig{
+				User:     "test",
+				Password: "pass123",
+			},
+		}
+
+		var buf bytes.Buffer
+		logger := zerolog.New(&buf)
+		logger.Info().Interface("config", Redact(cfg)).Msg("loaded configuration")
+
+		logged := buf.String()
+		if strings.Contains(logged, "pass123") || strings.Contains(logged, "secret-key") {
+			t.Errorf("sensitive value found in log: %v", logged)
+			t.FailNow()
+		}
+		if !strings.Contains(logged, `"Password":"*****"`) || !strings.Contains(logged, `"APIKey":"*****"`) {
+			t.Errorf("expected masked values in log: %v", logged)
+			t.FailNow()
+		}
+		if !strings.Contains(logged, `"User":"test"`) {
+			t.Errorf("expected non-sensitive value in log: %v", logged)
+		}
+		if cfg.Database.Password != "pass123" {
+			t.Errorf("original config was modified: %v", cfg.Database.Password)
+		}
+	})
+
+	t.Run("leavesEmptyFields", func(t *testing.T) {
+		redacted := Redact(&testConfig{}).(testConfig)
+		if redacted.APIKey != "" || redacted.Database.Password != "" {
+			t.Errorf("unexpected masked empty value: %v", redacted)
+		}
+	})
+}
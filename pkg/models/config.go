@@ -3,3 +3,8 @@ package models
 type Logger struct {
 	Level string
 }
+
+type Renderer struct {
+	JSONEncoder string
+	JSONCharset string
+}
@@ -0,0 +1,81 @@
+package renderer
+
+import (
+	"fmt"
+	"io"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"github.com/unrolled/render"
+
+	"github.com/alexsniffin/go-api-starter/pkg/models"
+)
+
+const (
+	// StdlibEncoder encodes JSON with encoding/json, this is the default
+	StdlibEncoder = "stdlib"
+	// JsoniterEncoder encodes JSON with json-iterator/go configured to be compatible with encoding/json
+	JsoniterEncoder = "jsoniter"
+)
+
+// Renderer writes JSON responses
+type Renderer interface {
+	JSON(w io.Writer, status int, v interface{}) error
+}
+
+// Creates a Renderer for the configured JSON encoder. The JSON Content-Type only carries a charset parameter when
+// JSONCharset is set, a BOM is never written.
+func NewRenderer(cfg models.Renderer) (Renderer, error) {
+	newRender := render.New(render.Options{
+		Charset:        cfg.JSONCharset,
+		DisableCharset: cfg.JSONCharset == "",
+	})
+
+	switch cfg.JSONEncoder {
+	case "", StdlibEncoder:
+		return newRender, nil
+	case JsoniterEncoder:
+		contentType := render.ContentJSON
+		if cfg.JSONCharset != "" {
+			contentType = fmt.Sprint(contentType, "; charset=", cfg.JSONCharset)
+		}
+
+		return &jsoniterRenderer{
+			render:      newRender,
+			api:         jsoniter.ConfigCompatibleWithStandardLibrary,
+			contentType: contentType,
+		}, nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unsupported JSONEncoder: %s", cfg.JSONEncoder))
+	}
+}
+
+type jsoniterRenderer struct {
+	render      *render.Render
+	api         jsoniter.API
+	contentType string
+}
+
+// JSON marshals v with json-iterator and writes it with the same headers as render.JSON
+func (j *jsoniterRenderer) JSON(w io.Writer, status int, v interface{}) error {
+	result, err := j.api.Marshal(v)
+	if err != nil {
+		return j.render.Render(w, failedEngine{err: err}, nil)
+	}
+
+	return j.render.Render(w, render.Data{
+		Head: render.Head{
+			ContentType: j.contentType,
+			Status:      status,
+		},
+	}, result)
+}
+
+// failedEngine reports a marshal error through render so it's handled the same way as render.JSON
+type failedEngine struct {
+	err error
+}
+
+func (f failedEngine) Render(io.Writer, interface{}) error {
+	return f.err
+}
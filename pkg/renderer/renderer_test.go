@@ -0,0 +1,121 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pkgModels "github.com/alexsniffin/go-api-starter/pkg/models"
+)
+
+type testItem struct {
+	ID        int       `json:"id"`
+	Todo      string    `json:"todo"`
+	CreatedOn time.Time `json:"created_on"`
+}
+
+func newTestRenderer(t testing.TB, encoder string) Renderer {
+	r, err := NewRenderer(pkgModels.Renderer{JSONEncoder: encoder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRenderer(t *testing.T) {
+	t.Run("identicalOutput", func(t *testing.T) {
+		item := testItem{
+			ID:        1,
+			Todo:      `remember <the> "thing" & ünïcode`,
+			CreatedOn: time.Date(2020, 6, 1, 12, 30, 0, 500, time.FixedZone("EST", -5*60*60)),
+		}
+
+		stdlib := httptest.NewRecorder()
+		if err := newTestRenderer(t, StdlibEncoder).JSON(stdlib, http.StatusOK, item); err != nil {
+			t.Fatal(err)
+		}
+
+		jsoniter := httptest.NewRecorder()
+		if err := newTestRenderer(t, JsoniterEncoder).JSON(jsoniter, http.StatusOK, item); err != nil {
+			t.Fatal(err)
+		}
+
+		if stdlib.Body.String() != jsoniter.Body.String() {
+			t.Errorf("unexpected body: got %v want %v", jsoniter.Body.String(), stdlib.Body.String())
+		}
+		if stdlib.Header().Get("Content-Type") != jsoniter.Header().Get("Content-Type") {
+			t.Errorf("unexpected content type: got %v want %v",
+				jsoniter.Header().Get("Content-Type"), stdlib.Header().Get("Content-Type"))
+		}
+		if stdlib.Code != jsoniter.Code {
+			t.Errorf("unexpected status code: got %v want %v", jsoniter.Code, stdlib.Code)
+		}
+	})
+
+	t.Run("contentType", func(t *testing.T) {
+		cases := []struct {
+			charset  string
+			expected string
+		}{
+			{"", "application/json"},
+			{"utf-8", "application/json; charset=utf-8"},
+		}
+		for _, c := range cases {
+			for _, encoder := range []string{StdlibEncoder, JsoniterEncoder} {
+				r, err := NewRenderer(pkgModels.Renderer{JSONEncoder: encoder, JSONCharset: c.charset})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				rr := httptest.NewRecorder()
+				if err := r.JSON(rr, http.StatusOK, testItem{ID: 1, Todo: "test"}); err != nil {
+					t.Fatal(err)
+				}
+
+				if contentType := rr.Header().Get("Content-Type"); contentType != c.expected {
+					t.Errorf("unexpected content type for %v: got %v want %v", encoder, contentType, c.expected)
+				}
+				if bytes.HasPrefix(rr.Body.Bytes(), []byte("\xEF\xBB\xBF")) {
+					t.Errorf("unexpected BOM in body for %v", encoder)
+				}
+			}
+		}
+	})
+
+	t.Run("unsupportedEncoder", func(t *testing.T) {
+		if _, err := NewRenderer(pkgModels.Renderer{JSONEncoder: "unknown"}); err == nil {
+			t.Error("expected error for unsupported encoder")
+		}
+	})
+}
+
+func benchmarkRenderer(b *testing.B, encoder string) {
+	items := make([]testItem, 100)
+	for i := range items {
+		items[i] = testItem{
+			ID:        i + 1,
+			Todo:      fmt.Sprint("remember the thing that I needed todo ", i),
+			CreatedOn: time.Now(),
+		}
+	}
+	r := newTestRenderer(b, encoder)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := r.JSON(httptest.NewRecorder(), http.StatusOK, items); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderer_Stdlib(b *testing.B) {
+	benchmarkRenderer(b, StdlibEncoder)
+}
+
+func BenchmarkRenderer_Jsoniter(b *testing.B) {
+	benchmarkRenderer(b, JsoniterEncoder)
+}
@@ -0,0 +1,48 @@
+package signals
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// ReloadSignal triggers a config reload instead of a shutdown
+const ReloadSignal = syscall.SIGHUP
+
+var byName = map[string]os.Signal{
+	"SIGINT":  syscall.SIGINT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
+
+// Parse returns the signals for the names, e.g. `SIGTERM`. SIGHUP can't be used since it's reserved for reloading
+func Parse(names []string) ([]os.Signal, error) {
+	parsed := make([]os.Signal, 0, len(names))
+	for _, name := range names {
+		sig, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported signal: %s", name)
+		}
+		parsed = append(parsed, sig)
+	}
+	return parsed, nil
+}
+
+// Wait reads signals until one of the shutdown signals is received and returns it, reload is called for every
+// ReloadSignal received in the meantime. Other signals are ignored.
+func Wait(received <-chan os.Signal, shutdown []os.Signal, reload func()) os.Signal {
+	for sig := range received {
+		if sig == ReloadSignal {
+			reload()
+			continue
+		}
+		for _, s := range shutdown {
+			if sig == s {
+				return sig
+			}
+		}
+	}
+	return nil
+}
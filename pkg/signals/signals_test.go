@@ -0,0 +1,47 @@
+package signals
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		parsed, err := Parse([]string{"SIGINT", "SIGTERM"})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			t.FailNow()
+		}
+		if len(parsed) != 2 || parsed[0] != syscall.SIGINT || parsed[1] != syscall.SIGTERM {
+			t.Errorf("unexpected signals: %v", parsed)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, name := range []string{"SIGHUP", "SIGKILL", "TERM"} {
+			if _, err := Parse([]string{name}); err == nil {
+				t.Errorf("expected error for %v", name)
+			}
+		}
+	})
+}
+
+func TestWait(t *testing.T) {
+	received := make(chan os.Signal, 3)
+	received <- syscall.SIGHUP
+	received <- syscall.SIGUSR1
+	received <- syscall.SIGTERM
+
+	reloads := 0
+	stopped := Wait(received, []os.Signal{syscall.SIGINT, syscall.SIGTERM}, func() {
+		reloads++
+	})
+
+	if stopped != syscall.SIGTERM {
+		t.Errorf("unexpected shutdown signal: got %v want %v", stopped, syscall.SIGTERM)
+	}
+	if reloads != 1 {
+		t.Errorf("unexpected reloads: got %v want %v", reloads, 1)
+	}
+}